@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -112,6 +113,11 @@ func genIface(proto Protocol, clientDir string) error {
 
 		fmt.Fprintln(iFile, "}")
 
+		for _, v := range iface.Events {
+			fmt.Fprintf(iFile, "// %s holds the arguments of a %s.%s event.\n", eventStruct(iface, v), iface.Name, v.Name)
+			fmt.Fprintf(iFile, "type %s struct{\n%s\n}\n", eventStruct(iface, v), strings.Replace(makeArgs(v.Args), ",", "\n", -1))
+		}
+
 		for _, v := range iface.Enums {
 			outputDesc(iFile, v.Description)
 			etype := goify(iface.Name + "_" + v.Name)
@@ -123,8 +129,110 @@ func genIface(proto Protocol, clientDir string) error {
 			fmt.Fprintln(iFile, ")")
 		}
 
+		if err := genDescriptor(iFile, iface); err != nil {
+			iFile.Close()
+			return err
+		}
+
 		iFile.Close()
 	}
 
 	return nil
 }
+
+var argTypes = map[string]string{
+	"int":    "ArgInt",
+	"uint":   "ArgUint",
+	"fixed":  "ArgFixed",
+	"string": "ArgString",
+	"object": "ArgObject",
+	"new_id": "ArgNewId",
+	"array":  "ArgArray",
+	"fd":     "ArgFd",
+}
+
+func parseVersion(v string) (uint64, error) {
+	if v == "" {
+		return 1, nil
+	}
+	return strconv.ParseUint(v, 10, 32)
+}
+
+func eventStruct(iface Interface, ev Event) string {
+	return goify(iface.Name+"_"+ev.Name) + "Event"
+}
+
+func outputMessage(file io.Writer, name, msgType, since string, args []Arg, event string) error {
+	version, err := parseVersion(since)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(file, "{Name: %q, Since: %d, ", name, version)
+	if msgType == "destructor" {
+		fmt.Fprint(file, "Destructor: true, ")
+	}
+	fmt.Fprintln(file, "Args: []Arg{")
+	for _, v := range args {
+		argType, ok := argTypes[v.Type]
+		if !ok {
+			return fmt.Errorf("unknown arg type: %s", v.Type)
+		}
+		fmt.Fprintf(file, "{Name: %q, Type: %s", v.Name, argType)
+		if v.Interface != "" {
+			fmt.Fprintf(file, ", Interface: %q", v.Interface)
+		}
+		if v.AllowNull {
+			fmt.Fprint(file, ", AllowNull: true")
+		}
+		fmt.Fprintln(file, "},")
+	}
+	fmt.Fprint(file, "}")
+	if event != "" {
+		fmt.Fprintf(file, ", Event: %s{}", event)
+	}
+	fmt.Fprintln(file, "},")
+	return nil
+}
+
+// genDescriptor writes the wire description of iface, which the runtime uses
+// to encode and decode its messages.
+func genDescriptor(file io.Writer, iface Interface) error {
+	version, err := parseVersion(iface.Version)
+	if err != nil {
+		return err
+	}
+	name := goify(iface.Name) + "Interface"
+
+	fmt.Fprintf(file, "var %s = &Interface{\n", name)
+	fmt.Fprintf(file, "Name: %q,\nVersion: %d,\n", iface.Name, version)
+	fmt.Fprintln(file, "Requests: []Message{")
+	for _, v := range iface.Requests {
+		if err := outputMessage(file, v.Name, v.Type, v.Since, v.Args, ""); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(file, "},")
+	fmt.Fprintln(file, "Events: []Message{")
+	for _, v := range iface.Events {
+		if err := outputMessage(file, v.Name, v.Type, v.Since, v.Args, eventStruct(iface, v)); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(file, "},")
+	fmt.Fprintln(file, "Enums: []Enum{")
+	for _, v := range iface.Enums {
+		fmt.Fprintf(file, "{Name: %q, Entries: []EnumEntry{\n", v.Name)
+		for _, w := range v.Entries {
+			value, err := strconv.ParseUint(w.Value, 0, 32)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(file, "{Name: %q, Value: %d},\n", w.Name, value)
+		}
+		fmt.Fprintln(file, "}},")
+	}
+	fmt.Fprintln(file, "},")
+	fmt.Fprintln(file, "}")
+	fmt.Fprintf(file, "func init() { registerInterface(%s) }\n", name)
+	return nil
+}
@@ -0,0 +1,271 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// An Object is anything that names a protocol object, such as a client proxy
+// or a server resource. Object arguments may be given as an Object or a
+// WlObject.
+type Object interface {
+	Id() uint32
+}
+
+func putUint(bs []byte, v uint32) []byte {
+	var w [4]byte
+	*(*uint32)(unsafe.Pointer(&w[0])) = v
+	return append(bs, w[:]...)
+}
+
+func getUint(bs []byte) (uint32, []byte, error) {
+	if len(bs) < 4 {
+		return 0, nil, errors.New("getUint: not enough data")
+	}
+	return *(*uint32)(unsafe.Pointer(&bs[0])), bs[4:], nil
+}
+
+// putBytes appends a length prefixed, zero padded byte string.
+func putBytes(bs []byte, v []byte, length uint32) []byte {
+	bs = putUint(bs, length)
+	bs = append(bs, v...)
+	for i := uint32(len(v)); i < length; i++ {
+		bs = append(bs, 0)
+	}
+	for pad := (4 - int(length)%4) % 4; pad > 0; pad-- {
+		bs = append(bs, 0)
+	}
+	return bs
+}
+
+func getBytes(bs []byte) ([]byte, []byte, error) {
+	length, bs, err := getUint(bs)
+	if err != nil {
+		return nil, nil, err
+	}
+	padded := (int(length) + 3) &^ 3
+	if length > maxMsgSize || len(bs) < padded {
+		return nil, nil, errors.New("getBytes: not enough data")
+	}
+	return bs[:length], bs[padded:], nil
+}
+
+func toFixed(f WlFixed) uint32 {
+	return uint32(int32(math.Round(float64(f) * 256)))
+}
+
+func fromFixed(v uint32) WlFixed {
+	return WlFixed(float64(int32(v)) / 256)
+}
+
+func objectId(v interface{}) (uint32, bool) {
+	switch v := v.(type) {
+	case WlObject:
+		return uint32(v), true
+	case nil:
+		return 0, true
+	case Object:
+		if v == nil {
+			return 0, true
+		}
+		return v.Id(), true
+	}
+	return 0, false
+}
+
+// NewMessage encodes a request or event with the given arguments, which
+// must have the gen types matching m's signature. A new_id argument without
+// a fixed interface is given as its interface name, version and ID. The file
+// descriptors to send along with the message are returned separately.
+func NewMessage(id uint32, op uint16, m *Message, args ...interface{}) (WlMessage, []int, error) {
+	bs := make([]byte, 0, 64)
+	var fds []int
+	argErr := func(a Arg, v interface{}) error {
+		return fmt.Errorf("NewMessage: %s.%s: invalid value %v (%T) for %c argument", m.Name, a.Name, v, v, a.Type)
+	}
+
+	for _, a := range m.Args {
+		if a.Type == ArgNewId && a.Interface == "" {
+			if len(args) < 3 {
+				return WlMessage{}, nil, fmt.Errorf("NewMessage: %s: not enough arguments", m.Name)
+			}
+			iface, ok1 := args[0].(WlString)
+			version, ok2 := args[1].(WlUint)
+			if !ok1 || !ok2 {
+				return WlMessage{}, nil, argErr(a, args[:2])
+			}
+			bs = putBytes(bs, []byte(iface), uint32(len(iface)+1))
+			bs = putUint(bs, uint32(version))
+			args = args[2:]
+		}
+		if len(args) == 0 {
+			return WlMessage{}, nil, fmt.Errorf("NewMessage: %s: not enough arguments", m.Name)
+		}
+		v := args[0]
+		args = args[1:]
+
+		switch a.Type {
+		case ArgInt:
+			i, ok := v.(WlInt)
+			if !ok {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			bs = putUint(bs, uint32(i))
+		case ArgUint:
+			u, ok := v.(WlUint)
+			if !ok {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			bs = putUint(bs, uint32(u))
+		case ArgFixed:
+			f, ok := v.(WlFixed)
+			if !ok {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			bs = putUint(bs, toFixed(f))
+		case ArgString:
+			s, ok := v.(WlString)
+			if !ok {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			if s == "" && a.AllowNull {
+				bs = putUint(bs, 0)
+			} else {
+				bs = putBytes(bs, []byte(s), uint32(len(s)+1))
+			}
+		case ArgObject:
+			o, ok := objectId(v)
+			if !ok || (o == 0 && !a.AllowNull) {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			bs = putUint(bs, o)
+		case ArgNewId:
+			n, ok := v.(WlNewId)
+			if !ok {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			bs = putUint(bs, uint32(n))
+		case ArgArray:
+			arr, ok := v.(WlArray)
+			if !ok {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			bs = putBytes(bs, arr, uint32(len(arr)))
+		case ArgFd:
+			fd, ok := v.(WlFd)
+			if !ok {
+				return WlMessage{}, nil, argErr(a, v)
+			}
+			fds = append(fds, int(fd))
+		default:
+			return WlMessage{}, nil, fmt.Errorf("NewMessage: unknown argument type %c", a.Type)
+		}
+	}
+	if len(args) != 0 {
+		return WlMessage{}, nil, fmt.Errorf("NewMessage: %s: too many arguments", m.Name)
+	}
+
+	size := headerSize + len(bs)
+	if size > maxMsgSize {
+		return WlMessage{}, nil, fmt.Errorf("NewMessage: %s: message too large (%d bytes)", m.Name, size)
+	}
+	return WlMessage{
+		WlHeader: WlHeader{Id: id, Op: op, Size: uint16(size)},
+		Data:     bs,
+	}, fds, nil
+}
+
+// UnmarshalArgs decodes the arguments of a message with m's signature. The
+// values have the gen types used by the generated interfaces, with a new_id
+// argument without a fixed interface expanded to its interface name, version
+// and ID. File descriptors are taken from the front of fds and the rest are
+// returned; on error the ones taken are closed. Strings and arrays are copied
+// out of data.
+func UnmarshalArgs(m *Message, data []byte, fds []int) ([]interface{}, []int, error) {
+	args := make([]interface{}, 0, len(m.Args))
+	all := fds
+	var (
+		u   uint32
+		bs  []byte
+		err error
+	)
+	for _, a := range m.Args {
+		switch a.Type {
+		case ArgInt:
+			u, data, err = getUint(data)
+			args = append(args, WlInt(u))
+		case ArgUint:
+			u, data, err = getUint(data)
+			args = append(args, WlUint(u))
+		case ArgFixed:
+			u, data, err = getUint(data)
+			args = append(args, fromFixed(u))
+		case ArgString:
+			bs, data, err = getBytes(data)
+			if err == nil {
+				args, err = appendString(args, a, bs)
+			}
+		case ArgObject:
+			u, data, err = getUint(data)
+			if err == nil && u == 0 && !a.AllowNull {
+				err = fmt.Errorf("UnmarshalArgs: %s.%s: null object", m.Name, a.Name)
+			}
+			args = append(args, WlObject(u))
+		case ArgNewId:
+			if a.Interface == "" {
+				bs, data, err = getBytes(data)
+				if err == nil {
+					args, err = appendString(args, a, bs)
+				}
+				if err == nil {
+					u, data, err = getUint(data)
+					args = append(args, WlUint(u))
+				}
+				if err != nil {
+					break
+				}
+			}
+			u, data, err = getUint(data)
+			if err == nil && u == 0 {
+				err = fmt.Errorf("UnmarshalArgs: %s.%s: null new_id", m.Name, a.Name)
+			}
+			args = append(args, WlNewId(u))
+		case ArgArray:
+			bs, data, err = getBytes(data)
+			args = append(args, WlArray(append([]byte(nil), bs...)))
+		case ArgFd:
+			if len(fds) == 0 {
+				err = fmt.Errorf("UnmarshalArgs: %s.%s: missing file descriptor", m.Name, a.Name)
+				break
+			}
+			args = append(args, WlFd(fds[0]))
+			fds = fds[1:]
+		default:
+			err = fmt.Errorf("UnmarshalArgs: unknown argument type %c", a.Type)
+		}
+		if err != nil {
+			closeFDs(all[:len(all)-len(fds)])
+			return nil, fds, err
+		}
+	}
+	if len(data) != 0 {
+		closeFDs(all[:len(all)-len(fds)])
+		return nil, fds, fmt.Errorf("UnmarshalArgs: %s: %d trailing bytes", m.Name, len(data))
+	}
+	return args, fds, nil
+}
+
+func appendString(args []interface{}, a Arg, bs []byte) ([]interface{}, error) {
+	if len(bs) == 0 {
+		if !a.AllowNull {
+			return args, fmt.Errorf("UnmarshalArgs: %s: null string", a.Name)
+		}
+		return append(args, WlString("")), nil
+	}
+	if bs[len(bs)-1] != 0 {
+		return args, fmt.Errorf("UnmarshalArgs: %s: string is not NUL terminated", a.Name)
+	}
+	return append(args, WlString(bs[:len(bs)-1])), nil
+}
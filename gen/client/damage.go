@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/region"
+)
+
+// maxDamageRects is how many rectangles Flush sends before it settles for
+// their bounding box.
+const maxDamageRects = 32
+
+// A Damage tracks the parts of a surface a client has redrawn since its last
+// commit, and sends them as wl_surface.damage requests. Overlapping and
+// touching rectangles are merged first, so a frame drawn in many small
+// pieces costs few requests. A Damage may be used from several goroutines.
+type Damage struct {
+	surface *Proxy
+
+	mu     sync.Mutex
+	region region.Region
+}
+
+// NewDamage tracks damage for a wl_surface proxy.
+func NewDamage(surface *Proxy) (*Damage, error) {
+	if surface.Interface() != gen.WlSurfaceInterface {
+		return nil, fmt.Errorf("NewDamage: %s is not a wl_surface", surface.Interface().Name)
+	}
+	return &Damage{surface: surface}, nil
+}
+
+// Add marks a rectangle of the surface, in surface local coordinates, as
+// redrawn.
+func (d *Damage) Add(r image.Rectangle) {
+	d.mu.Lock()
+	d.region = d.region.UnionRect(r)
+	d.mu.Unlock()
+}
+
+// Region returns the damage added since the last Flush.
+func (d *Damage) Region() region.Region {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.region
+}
+
+// Flush sends the damage added since the last Flush and forgets it. It is
+// called just before wl_surface.commit. Damage made of more than a few
+// dozen rectangles is sent as its bounding box. It gives up when ctx is
+// done, as Proxy.Marshal does.
+func (d *Damage) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	dmg := d.region
+	d.region = region.Region{}
+	d.mu.Unlock()
+
+	rects := dmg.Rects()
+	if len(rects) > maxDamageRects {
+		rects = []image.Rectangle{dmg.Bounds()}
+	}
+	for _, r := range rects {
+		err := d.surface.Marshal(ctx, 2, gen.WlInt(r.Min.X), gen.WlInt(r.Min.Y), gen.WlInt(r.Dx()), gen.WlInt(r.Dy()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/Pursuit92/goland/gen"
+	"golang.org/x/sys/unix"
+)
+
+// An event is a decoded event waiting to be dispatched.
+type event struct {
+	proxy  *Proxy
+	opcode uint16
+	args   []interface{}
+}
+
+// readEvents blocks until data arrives from the server and queues the events
+// it contains. The caller holds mu, which is released while blocked, and no
+// other goroutine may be reading. Connection errors are sticky; ctx errors
+// are not.
+func (d *Display) readEvents(ctx context.Context) error {
+	readDone := make(chan struct{})
+	d.readDone = readDone
+	d.mu.Unlock()
+	msgs, err := d.conn.ReadMessages(ctx, d.msgs[:0])
+	d.mu.Lock()
+	d.readDone = nil
+	defer close(readDone)
+
+	d.msgs = msgs[:0]
+	for _, msg := range msgs {
+		if qerr := d.queueEvent(msg); qerr != nil {
+			err = qerr
+			break
+		}
+	}
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+	return d.err
+}
+
+// waitRead waits for the goroutine reading from the connection to finish.
+// The caller holds mu, which is released while waiting.
+func (d *Display) waitRead(ctx context.Context) error {
+	readDone := d.readDone
+	d.mu.Unlock()
+	defer d.mu.Lock()
+	select {
+	case <-readDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// queueEvent decodes msg and appends it to the queue of the object it is
+// addressed to. Objects created by the event are added to the object map
+// right away, since later events in the same batch may already be addressed
+// to them. The caller holds mu.
+func (d *Display) queueEvent(msg gen.WlMessage) error {
+	p := d.objects.lookup(msg.Id)
+	if p == nil {
+		// Destroyed objects stay in the map as zombies, so this ID was
+		// never announced. The event cannot be decoded and is dropped, as
+		// in libwayland.
+		return nil
+	}
+	if p.zombie {
+		return d.objects.consumeZombieEvent(d.conn, p, msg)
+	}
+	if int(msg.Op) >= len(p.iface.Events) {
+		return fmt.Errorf("queueEvent: invalid opcode %d for %s@%d", msg.Op, p.iface.Name, p.id)
+	}
+	ev := &p.iface.Events[msg.Op]
+	args, err := d.conn.Unmarshal(ev, msg)
+	if err != nil {
+		return err
+	}
+	for i, a := range ev.Args {
+		if a.Type != gen.ArgNewId {
+			continue
+		}
+		iface := gen.LookupInterface(a.Interface)
+		if iface == nil {
+			return fmt.Errorf("queueEvent: unknown interface %q", a.Interface)
+		}
+		if _, err := d.newServerProxy(uint32(args[i].(gen.WlNewId)), iface, p.version, p.queue); err != nil {
+			return err
+		}
+	}
+
+	if p == &d.Proxy {
+		// wl_display events are handled as they are read, so that IDs are
+		// released no matter which queues the application dispatches.
+		d.handleDisplayEvent(msg.Op, args)
+		return nil
+	}
+	p.queue.events = append(p.queue.events, event{proxy: p, opcode: msg.Op, args: args})
+	return nil
+}
+
+// handleDisplayEvent handles an event sent to the wl_display. The caller
+// holds mu.
+func (d *Display) handleDisplayEvent(op uint16, args []interface{}) {
+	switch op {
+	case 0: // error
+		if d.err == nil {
+			d.err = d.protocolError(args[0].(gen.WlObject), args[1].(gen.WlUint), args[2].(gen.WlString))
+		}
+	case 1: // delete_id
+		d.objects.deleteId(uint32(args[0].(gen.WlUint)))
+	}
+}
+
+// dispatchEvent calls the handler method for ev and delivers it to the
+// subscriptions for its object and interface. Events for objects that were
+// destroyed after the event was queued, and events nobody handles, are
+// dropped and their file descriptors closed. The caller holds mu, which is
+// released while the event is delivered.
+func (d *Display) dispatchEvent(ev event) {
+	p := ev.proxy
+	if p.zombie {
+		closeArgFDs(ev.args)
+		return
+	}
+	var method reflect.Value
+	if p.methods != nil {
+		method = p.methods[ev.opcode]
+	}
+	subs := append(append([]*Subscription(nil), p.subs...), d.ifaceSubs[p.iface]...)
+
+	receivers := len(subs)
+	if method.IsValid() {
+		receivers++
+	}
+	if receivers == 0 {
+		closeArgFDs(ev.args)
+		return
+	}
+
+	// Every receiver owns the file descriptors it is given, so each one
+	// after the first gets duplicates, made before anyone can close the
+	// originals.
+	msg := &p.iface.Events[ev.opcode]
+	copies := make([][]interface{}, receivers)
+	copies[0] = ev.args
+	for i := 1; i < receivers; i++ {
+		copies[i] = ev.args
+		if msg.NumFDs() > 0 {
+			copies[i] = dupArgFDs(ev.args)
+		}
+	}
+
+	d.mu.Unlock()
+	defer d.mu.Lock()
+	if method.IsValid() {
+		gen.CallMethod(method, copies[0])
+		copies = copies[1:]
+	}
+	for i, s := range subs {
+		if !s.deliver(Event{Proxy: p, Value: eventValue(msg, copies[i])}) {
+			closeArgFDs(copies[i])
+		}
+	}
+}
+
+func dupArgFDs(args []interface{}) []interface{} {
+	dup := append([]interface{}(nil), args...)
+	for i, v := range dup {
+		if fd, ok := v.(gen.WlFd); ok {
+			nfd, err := unix.FcntlInt(uintptr(fd), unix.F_DUPFD_CLOEXEC, 0)
+			if err != nil {
+				nfd = -1
+			}
+			dup[i] = gen.WlFd(nfd)
+		}
+	}
+	return dup
+}
+
+func closeArgFDs(args []interface{}) {
+	for _, v := range args {
+		if fd, ok := v.(gen.WlFd); ok {
+			unix.Close(int(fd))
+		}
+	}
+}
+
+// DispatchPending dispatches the events on the default queue that have
+// already been read, without reading any more. It returns the number of
+// events dispatched.
+func (d *Display) DispatchPending() (int, error) {
+	return d.queue.DispatchPending()
+}
+
+// Dispatch dispatches the events on the default queue. If there are none it
+// first blocks until some arrive or ctx is done. It returns the number of
+// events dispatched.
+func (d *Display) Dispatch(ctx context.Context) (int, error) {
+	return d.queue.Dispatch(ctx)
+}
+
+// Roundtrip blocks until the server has processed every request sent so
+// far, dispatching the default queue as events arrive. Since the server
+// replies to the requests in order, once it returns all the events they
+// caused, such as the initial wl_registry.global burst, have been
+// dispatched. It returns the number of events dispatched, and gives up when
+// ctx is done.
+func (d *Display) Roundtrip(ctx context.Context) (int, error) {
+	return d.queue.Roundtrip(ctx)
+}
+
+type syncListener struct {
+	proxy *Proxy
+	done  bool
+}
+
+func (l *syncListener) Done(CallbackData gen.WlUint) {
+	l.done = true
+	l.proxy.Destroy()
+}
+
+// Sync sends wl_display.sync. The returned wl_callback proxy's Done event
+// fires once the server has processed every earlier request.
+func (d *Display) Sync(ctx context.Context) (*Proxy, error) {
+	return d.MarshalConstructor(ctx, 0, gen.WlCallbackInterface, gen.WlNewId(0))
+}
+
+// GetRegistry sends wl_display.get_registry and returns the wl_registry
+// proxy.
+func (d *Display) GetRegistry(ctx context.Context) (*Proxy, error) {
+	return d.MarshalConstructor(ctx, 1, gen.WlRegistryInterface, gen.WlNewId(0))
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/Pursuit92/goland/gen"
+	"golang.org/x/sys/unix"
+)
+
+// A Display is a client connection to a compositor. It is also the proxy for
+// the wl_display singleton, which is always object 1.
+//
+// The object map, the event queues and the read state are guarded by mu.
+// Only one goroutine reads from the connection at a time; while it does,
+// readDone is open and the others wait for it to be closed.
+//
+// Requests may be sent from any goroutine. The send lock is held from
+// allocating a new object's ID until the request creating it is written, so
+// new IDs reach the server in the order they were allocated, and requests
+// from one goroutine are written in the order they were made. It is a
+// channel so that waiting for it can be given up on.
+type Display struct {
+	Proxy
+
+	conn     *gen.Conn
+	sendLock chan struct{}
+
+	mu       sync.Mutex
+	objects  objectMap
+	queue    *EventQueue
+	readDone chan struct{}
+	err      error
+	msgs     []gen.WlMessage
+
+	ifaceSubs map[*gen.Interface][]*Subscription
+}
+
+// Connect opens a connection to a compositor the same way libwayland's
+// wl_display_connect does.
+//
+// If WAYLAND_SOCKET is set, it holds the number of an already connected file
+// descriptor inherited from the parent process, and name is ignored. The
+// variable is unset so that it is not passed on to our own children.
+//
+// Otherwise the socket is named by name, or WAYLAND_DISPLAY if name is empty,
+// or "wayland-0" if both are empty. An absolute name is used as the socket
+// path as is; a relative one is looked up in XDG_RUNTIME_DIR.
+func Connect(name string) (*Display, error) {
+	conn, err := Dial(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewDisplay(conn), nil
+}
+
+// NewDisplay wraps an already established compositor connection.
+func NewDisplay(conn *net.UnixConn) *Display {
+	d := &Display{conn: gen.NewConn(conn), sendLock: make(chan struct{}, 1)}
+	d.queue = &EventQueue{display: d}
+	d.Proxy = Proxy{iface: gen.WlDisplayInterface, version: 1, display: d, queue: d.queue}
+	d.objects.insertNew(&d.Proxy)
+	return d
+}
+
+// Close closes the connection to the compositor.
+func (d *Display) Close() error {
+	return d.conn.Close()
+}
+
+// lockSend takes the send lock, giving up when ctx is done.
+func (d *Display) lockSend(ctx context.Context) error {
+	select {
+	case d.sendLock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Display) unlockSend() {
+	<-d.sendLock
+}
+
+// Object returns the live proxy for id, or nil if there is none.
+func (d *Display) Object(id uint32) *Proxy {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p := d.objects.lookup(id)
+	if p == nil || p.zombie {
+		return nil
+	}
+	return p
+}
+
+// DefaultQueue returns the queue that objects are assigned to unless they
+// are created through a proxy on another queue.
+func (d *Display) DefaultQueue() *EventQueue {
+	return d.queue
+}
+
+// newProxy creates a proxy for a client created object. The caller holds mu.
+func (d *Display) newProxy(iface *gen.Interface, version uint32, queue *EventQueue) (*Proxy, error) {
+	p := &Proxy{iface: iface, version: version, display: d, queue: queue}
+	if err := d.objects.insertNew(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newServerProxy creates a proxy for an object the server created with id,
+// as announced by a new_id argument of an event. The caller holds mu.
+func (d *Display) newServerProxy(id uint32, iface *gen.Interface, version uint32, queue *EventQueue) (*Proxy, error) {
+	p := &Proxy{id: id, iface: iface, version: version, display: d, queue: queue}
+	if err := d.objects.insertAt(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Dial resolves the compositor socket as described for Connect and returns
+// the raw connection to it.
+func Dial(name string) (*net.UnixConn, error) {
+	if sock, ok := os.LookupEnv("WAYLAND_SOCKET"); ok {
+		os.Unsetenv("WAYLAND_SOCKET")
+		return socketFromFD(sock)
+	}
+
+	path, err := SocketPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+}
+
+// SocketPath returns the filesystem path of the compositor socket for name,
+// applying the WAYLAND_DISPLAY and XDG_RUNTIME_DIR rules described for
+// Connect.
+func SocketPath(name string) (string, error) {
+	return gen.SocketPath(name)
+}
+
+func socketFromFD(sock string) (*net.UnixConn, error) {
+	fd, err := strconv.Atoi(sock)
+	if err != nil || fd < 0 {
+		return nil, fmt.Errorf("Dial: invalid WAYLAND_SOCKET %q", sock)
+	}
+	unix.CloseOnExec(fd)
+
+	f := os.NewFile(uintptr(fd), "WAYLAND_SOCKET")
+	defer f.Close()
+	conn, err := net.FileConn(f)
+	if err != nil {
+		return nil, err
+	}
+	uconn, ok := conn.(*net.UnixConn)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("Dial: WAYLAND_SOCKET is not a unix socket")
+	}
+	return uconn, nil
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// A ProtocolError is a fatal error the server reported with wl_display.error.
+// Once one arrives the connection is unusable: every blocked and later call
+// on the Display and its proxies returns it.
+type ProtocolError struct {
+	ObjectId uint32
+	// Interface is the interface of the object, or "" if the client does
+	// not know the object.
+	Interface string
+	Code      uint32
+	// Name is the code's entry in the interface's error enum, such as
+	// "invalid_scale", or "" if the code is not in the enum.
+	Name    string
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	obj := fmt.Sprintf("object %d", e.ObjectId)
+	code := fmt.Sprintf("error %d", e.Code)
+	if e.Interface != "" {
+		obj = fmt.Sprintf("%s@%d", e.Interface, e.ObjectId)
+		if e.Name != "" {
+			code = fmt.Sprintf("%s.%s", e.Interface, e.Name)
+		}
+	}
+	return fmt.Sprintf("protocol error on %s: %s: %s", obj, code, e.Message)
+}
+
+// A VersionError is returned when a request is not available in the version
+// of the object it is sent on. Sending it anyway would get the client
+// disconnected.
+type VersionError struct {
+	Interface string
+	Request   string
+	Since     uint32
+	Version   uint32
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s.%s requires version %d, object has version %d", e.Interface, e.Request, e.Since, e.Version)
+}
+
+// protocolError builds the error for a wl_display.error event. The caller
+// holds mu.
+func (d *Display) protocolError(objectId gen.WlObject, code gen.WlUint, message gen.WlString) *ProtocolError {
+	e := &ProtocolError{
+		ObjectId: uint32(objectId),
+		Code:     uint32(code),
+		Message:  string(message),
+	}
+	if p := d.objects.lookup(e.ObjectId); p != nil {
+		e.Interface = p.iface.Name
+		e.Name = p.iface.EnumEntryName("error", e.Code)
+	}
+	return e
+}
+
+// Err returns the error that made the connection unusable, such as a
+// *ProtocolError, or nil if it is still healthy.
+func (d *Display) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
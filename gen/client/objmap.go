@@ -0,0 +1,142 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// Object IDs from 1 up to serverIdStart-1 are allocated by the client, and
+// IDs from serverIdStart up by the server.
+const (
+	serverIdStart = 0xff000000
+	maxClientId   = serverIdStart - 1
+)
+
+// objectMap tracks the live objects of a connection. Slot i of client holds
+// object i+1 and slot i of server holds object serverIdStart+i. A nil slot is
+// unused.
+//
+// A client allocated ID whose proxy has been destroyed stays in the map as a
+// zombie until the server acknowledges the destruction with
+// wl_display.delete_id. Until then events may still arrive for it, and the
+// ID must not be handed out again. A server allocated ID stays as a zombie
+// until the server reuses it, so that events still addressed to it can be
+// decoded.
+type objectMap struct {
+	client []*Proxy
+	server []*Proxy
+	free   []uint32
+}
+
+// insertNew allocates a client side ID for p.
+func (m *objectMap) insertNew(p *Proxy) error {
+	if n := len(m.free); n > 0 {
+		p.id = m.free[n-1]
+		m.free = m.free[:n-1]
+		m.client[p.id-1] = p
+		return nil
+	}
+	if len(m.client) >= maxClientId {
+		return errors.New("insertNew: out of client object IDs")
+	}
+	m.client = append(m.client, p)
+	p.id = uint32(len(m.client))
+	return nil
+}
+
+// insertAt records p under the ID it was assigned by the server, which may
+// be that of a zombie.
+func (m *objectMap) insertAt(p *Proxy) error {
+	if p.id < serverIdStart {
+		return fmt.Errorf("insertAt: %d is not a server object ID", p.id)
+	}
+	i := int(p.id - serverIdStart)
+	if i > len(m.server) {
+		return fmt.Errorf("insertAt: server object ID %d skips unused IDs", p.id)
+	}
+	if i == len(m.server) {
+		m.server = append(m.server, p)
+		return nil
+	}
+	if q := m.server[i]; q != nil && !q.zombie {
+		return fmt.Errorf("insertAt: server object ID %d is already in use", p.id)
+	}
+	m.server[i] = p
+	return nil
+}
+
+// lookup returns the proxy or zombie for id, or nil.
+func (m *objectMap) lookup(id uint32) *Proxy {
+	if id >= serverIdStart {
+		if i := int(id - serverIdStart); i < len(m.server) {
+			return m.server[i]
+		}
+		return nil
+	}
+	if id == 0 || int(id) > len(m.client) {
+		return nil
+	}
+	return m.client[id-1]
+}
+
+// remove marks the object destroyed on the client side. Client allocated IDs
+// the server has already deleted are released at once; other IDs become
+// zombies.
+func (m *objectMap) remove(p *Proxy) {
+	p.zombie = true
+	if p.id < serverIdStart && p.idDeleted {
+		m.release(p.id)
+	}
+}
+
+// deleteId handles the server's acknowledgement that a client allocated ID
+// is no longer in use. The ID is released if the proxy has been destroyed,
+// and otherwise as soon as it is.
+func (m *objectMap) deleteId(id uint32) error {
+	if id == 0 || id >= serverIdStart || int(id) > len(m.client) || m.client[id-1] == nil {
+		return fmt.Errorf("deleteId: %d is not a live client object ID", id)
+	}
+	if p := m.client[id-1]; !p.zombie {
+		p.idDeleted = true
+		return nil
+	}
+	m.release(id)
+	return nil
+}
+
+func (m *objectMap) release(id uint32) {
+	m.client[id-1] = nil
+	m.free = append(m.free, id)
+}
+
+// consumeZombieEvent swallows an event sent to a destroyed object, closing
+// any file descriptors it carries. Objects the event creates are recorded as
+// zombies: the server has allocated their IDs and may send events to them
+// before it learns that nobody is listening.
+func (m *objectMap) consumeZombieEvent(conn *gen.Conn, p *Proxy, msg gen.WlMessage) error {
+	if int(msg.Op) >= len(p.iface.Events) {
+		return fmt.Errorf("consumeZombieEvent: invalid opcode %d for %s", msg.Op, p.iface.Name)
+	}
+	ev := &p.iface.Events[msg.Op]
+	args, err := conn.Unmarshal(ev, msg)
+	if err != nil {
+		return err
+	}
+	closeArgFDs(args)
+	for i, a := range ev.Args {
+		if a.Type != gen.ArgNewId {
+			continue
+		}
+		iface := gen.LookupInterface(a.Interface)
+		if iface == nil {
+			return fmt.Errorf("consumeZombieEvent: unknown interface %q", a.Interface)
+		}
+		z := &Proxy{id: uint32(args[i].(gen.WlNewId)), iface: iface, version: p.version, display: p.display, zombie: true}
+		if err := m.insertAt(z); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"syscall"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// A Proxy is the client side handle for a protocol object.
+type Proxy struct {
+	id      uint32
+	iface   *gen.Interface
+	version uint32
+	display *Display
+	queue   *EventQueue
+
+	zombie    bool
+	idDeleted bool
+	wrapper   bool
+
+	handler interface{}
+	methods []reflect.Value
+	subs    []*Subscription
+}
+
+// Id returns the object ID of the proxy.
+func (p *Proxy) Id() uint32 {
+	return p.id
+}
+
+// Interface returns the description of the interface the object implements.
+func (p *Proxy) Interface() *gen.Interface {
+	return p.iface
+}
+
+// Version returns the interface version the object was created with.
+func (p *Proxy) Version() uint32 {
+	return p.version
+}
+
+// Display returns the connection the proxy belongs to.
+func (p *Proxy) Display() *Display {
+	return p.display
+}
+
+// Destroy forgets the object on the client side. It does not send a
+// destructor request. Events the server sent before it learned of the
+// destruction are discarded, and the object ID is reused only once the server
+// has acknowledged it with wl_display.delete_id.
+func (p *Proxy) Destroy() {
+	d := p.display
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if p.zombie || p.wrapper {
+		return
+	}
+	d.objects.remove(p)
+}
+
+// Queue returns the event queue the object's events are delivered to.
+func (p *Proxy) Queue() *EventQueue {
+	p.display.mu.Lock()
+	defer p.display.mu.Unlock()
+	return p.queue
+}
+
+// SetQueue moves the object to queue q, or back to the display's default
+// queue if q is nil. Events already queued stay where they are.
+func (p *Proxy) SetQueue(q *EventQueue) {
+	d := p.display
+	if q == nil {
+		q = d.queue
+	}
+	d.mu.Lock()
+	p.queue = q
+	d.mu.Unlock()
+}
+
+// Wrapper returns a wrapper for p, like libwayland's wl_proxy_create_wrapper.
+// Requests sent through the wrapper go to the wrapped object, but objects
+// they create are assigned to the wrapper's queue rather than p's. Since a
+// new object is put on its queue before the request creating it is sent,
+// none of its events can be dispatched from the wrong queue:
+//
+//	w := display.Wrapper()
+//	w.SetQueue(q)
+//	registry, err := w.MarshalConstructor(ctx, 1, gen.WlRegistryInterface, gen.WlNewId(0))
+//
+// A wrapper never receives events and destroying it has no effect on p.
+func (p *Proxy) Wrapper() *Proxy {
+	d := p.display
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &Proxy{
+		id:      p.id,
+		iface:   p.iface,
+		version: p.version,
+		display: d,
+		queue:   p.queue,
+		wrapper: true,
+	}
+}
+
+// SetHandler sets the value whose methods are called for the object's
+// events. The methods are named and typed as in the generated interface, so
+// any implementation of, say, gen.WlRegistry can be used for a wl_registry
+// proxy, but h only needs the methods for the events it cares about. Events
+// without a method are discarded.
+//
+// File descriptors passed to a handler method belong to it.
+func (p *Proxy) SetHandler(h interface{}) error {
+	if p.wrapper {
+		return errors.New("SetHandler: proxy wrappers do not receive events")
+	}
+	d := p.display
+	if h == nil {
+		d.mu.Lock()
+		p.handler, p.methods = nil, nil
+		d.mu.Unlock()
+		return nil
+	}
+
+	methods, err := gen.BindMethods(h, p.iface.Events)
+	if err != nil {
+		return fmt.Errorf("SetHandler: %s: %v", p.iface.Name, err)
+	}
+	d.mu.Lock()
+	p.handler, p.methods = h, methods
+	d.mu.Unlock()
+	return nil
+}
+
+// Handler returns the value set with SetHandler.
+func (p *Proxy) Handler() interface{} {
+	p.display.mu.Lock()
+	defer p.display.mu.Unlock()
+	return p.handler
+}
+
+// Marshal sends the request with the given opcode. The arguments have the
+// types used by the generated interface. If the request is a destructor the
+// proxy is destroyed. Requests newer than the proxy's version fail with a
+// *VersionError without being sent.
+//
+// Marshal blocks while the socket is full, and gives up when ctx is done. A
+// request given up on before any of it was written is not sent, and the
+// error is ctx's; one cut short halfway breaks the connection.
+func (p *Proxy) Marshal(ctx context.Context, opcode uint16, args ...interface{}) error {
+	req, err := p.request(opcode)
+	if err != nil {
+		return err
+	}
+	d := p.display
+	if err := d.lockSend(ctx); err != nil {
+		return err
+	}
+	defer d.unlockSend()
+	if err := p.send(ctx, opcode, req, args); err != nil {
+		return err
+	}
+	if req.Destructor {
+		p.Destroy()
+	}
+	return nil
+}
+
+// MarshalConstructor sends a request that creates a new object of interface
+// iface and returns its proxy. The new object's ID is passed as a
+// gen.WlNewId(0) placeholder among args. The new object inherits p's
+// version, as a wl_pointer inherits the version of the wl_seat it was
+// created from. As in libwayland, the inherited version is not checked
+// against iface's: a wl_seat bound at version 4 creates a version 4
+// wl_pointer even though wl_pointer only goes up to 3. It blocks as Marshal
+// does.
+func (p *Proxy) MarshalConstructor(ctx context.Context, opcode uint16, iface *gen.Interface, args ...interface{}) (*Proxy, error) {
+	return p.marshalConstructor(ctx, opcode, iface, p.version, args)
+}
+
+// MarshalConstructorVersioned is like MarshalConstructor, but creates the new
+// object with the given version, as wl_registry.bind does. The version must
+// be one iface has.
+func (p *Proxy) MarshalConstructorVersioned(ctx context.Context, opcode uint16, iface *gen.Interface, version uint32, args ...interface{}) (*Proxy, error) {
+	if version == 0 || version > iface.Version {
+		return nil, fmt.Errorf("MarshalConstructor: %s has no version %d", iface.Name, version)
+	}
+	return p.marshalConstructor(ctx, opcode, iface, version, args)
+}
+
+func (p *Proxy) marshalConstructor(ctx context.Context, opcode uint16, iface *gen.Interface, version uint32, args []interface{}) (*Proxy, error) {
+	req, err := p.request(opcode)
+	if err != nil {
+		return nil, err
+	}
+	slot := -1
+	for i, v := range args {
+		if _, ok := v.(gen.WlNewId); ok {
+			slot = i
+			break
+		}
+	}
+	if slot < 0 {
+		return nil, fmt.Errorf("MarshalConstructor: %s.%s: no new_id placeholder", p.iface.Name, req.Name)
+	}
+
+	d := p.display
+	if err := d.lockSend(ctx); err != nil {
+		return nil, err
+	}
+	defer d.unlockSend()
+	d.mu.Lock()
+	np, err := d.newProxy(iface, version, p.queue)
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	args = append([]interface{}(nil), args...)
+	args[slot] = gen.WlNewId(np.id)
+	if err := p.send(ctx, opcode, req, args); err != nil {
+		// The server never heard of the ID, so it is free for reuse.
+		d.mu.Lock()
+		np.idDeleted = true
+		d.mu.Unlock()
+		np.Destroy()
+		return nil, err
+	}
+	return np, nil
+}
+
+func (p *Proxy) request(opcode uint16) (*gen.Message, error) {
+	p.display.mu.Lock()
+	zombie, err := p.zombie, p.display.err
+	p.display.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if zombie {
+		return nil, errors.New("Marshal: proxy has been destroyed")
+	}
+	if int(opcode) >= len(p.iface.Requests) {
+		return nil, fmt.Errorf("Marshal: invalid opcode %d for %s", opcode, p.iface.Name)
+	}
+	req := &p.iface.Requests[opcode]
+	if req.Since > p.version {
+		return nil, &VersionError{Interface: p.iface.Name, Request: req.Name, Since: req.Since, Version: p.version}
+	}
+	return req, nil
+}
+
+// send writes a request. The caller holds the send lock.
+func (p *Proxy) send(ctx context.Context, opcode uint16, req *gen.Message, args []interface{}) error {
+	msg, fds, err := gen.NewMessage(p.id, opcode, req, args...)
+	if err != nil {
+		return err
+	}
+	d := p.display
+	err = d.conn.WriteMessage(ctx, msg, fds)
+	switch {
+	case err == nil, err == context.Canceled, err == context.DeadlineExceeded:
+		// A bare ctx error means nothing was written.
+		return err
+	case errors.Is(err, syscall.EPIPE):
+		// The server hung up, most likely after sending a protocol error.
+		// As libwayland does, let the next read report why.
+		return nil
+	}
+	// Anything else leaves the stream in an unknown state.
+	d.mu.Lock()
+	if d.err == nil {
+		d.err = err
+	}
+	d.mu.Unlock()
+	return err
+}
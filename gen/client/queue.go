@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// An EventQueue holds the events of the objects assigned to it until they
+// are dispatched, like libwayland's wl_event_queue. Each queue is dispatched
+// independently, so the objects of, say, a rendering goroutine can be put on
+// their own queue and have their handlers run only on that goroutine.
+//
+// New objects are assigned to the queue of the object that created them; see
+// Proxy.SetQueue and Proxy.Wrapper.
+type EventQueue struct {
+	display *Display
+	events  []event
+}
+
+// NewQueue creates an event queue on d.
+func (d *Display) NewQueue() *EventQueue {
+	return &EventQueue{display: d}
+}
+
+// DispatchPending dispatches the events on q that have already been read,
+// without reading any more. It returns the number of events dispatched.
+func (q *EventQueue) DispatchPending() (int, error) {
+	d := q.display
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return q.dispatchPending(), d.err
+}
+
+// dispatchPending is DispatchPending with mu held.
+func (q *EventQueue) dispatchPending() int {
+	n := 0
+	for len(q.events) > 0 {
+		ev := q.events[0]
+		q.events[0] = event{}
+		q.events = q.events[1:]
+		q.display.dispatchEvent(ev)
+		n++
+	}
+	return n
+}
+
+// Dispatch dispatches the events on q. If there are none it first blocks
+// until some arrive, reading from the connection or, if another goroutine is
+// already reading, waiting for it to queue them. It returns the number of
+// events dispatched, and gives up when ctx is done.
+func (q *EventQueue) Dispatch(ctx context.Context) (int, error) {
+	d := q.display
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for len(q.events) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		var err error
+		if d.readDone != nil {
+			err = d.waitRead(ctx)
+		} else {
+			err = d.readEvents(ctx)
+		}
+		if err != nil && len(q.events) == 0 {
+			return 0, err
+		}
+	}
+	return q.dispatchPending(), nil
+}
+
+// Roundtrip blocks until the server has processed every request sent so
+// far, dispatching q as events arrive. It returns the number of events
+// dispatched, and gives up when ctx is done.
+func (q *EventQueue) Roundtrip(ctx context.Context) (int, error) {
+	w := q.display.Wrapper()
+	w.SetQueue(q)
+	cb, err := w.MarshalConstructor(ctx, 0, gen.WlCallbackInterface, gen.WlNewId(0))
+	if err != nil {
+		return 0, err
+	}
+	done := &syncListener{proxy: cb}
+	cb.SetHandler(done)
+
+	total := 0
+	for !done.done {
+		n, err := q.Dispatch(ctx)
+		total += n
+		if err != nil {
+			cb.Destroy()
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Destroy discards the events still on q, closing any file descriptors they
+// carry. Objects assigned to q must be destroyed or moved to another queue
+// first.
+func (q *EventQueue) Destroy() {
+	d := q.display
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, ev := range q.events {
+		closeArgFDs(ev.args)
+	}
+	q.events = nil
+}
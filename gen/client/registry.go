@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// A Global is a global object announced by the server.
+type Global struct {
+	Name      uint32
+	Interface string
+	Version   uint32
+}
+
+type globalWatch struct {
+	added, removed func(Global)
+}
+
+// A Registry is a wl_registry proxy that keeps track of the server's globals.
+//
+// The usual startup sequence is:
+//
+//	reg, err := client.NewRegistry(ctx, display)
+//	...
+//	display.Roundtrip(ctx)
+//	compositor, err := reg.BindFirst(ctx, gen.WlCompositorInterface, 3)
+type Registry struct {
+	*Proxy
+
+	mu      sync.Mutex
+	globals map[uint32]Global
+	watches map[string][]globalWatch
+}
+
+// NewRegistry creates a registry on d's default queue. Its table fills up as
+// the wl_registry.global events are dispatched.
+func NewRegistry(ctx context.Context, d *Display) (*Registry, error) {
+	p, err := d.GetRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &Registry{
+		Proxy:   p,
+		globals: map[uint32]Global{},
+		watches: map[string][]globalWatch{},
+	}
+	if err := p.SetHandler(registryListener{r}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// registryListener handles the registry's events.
+type registryListener struct {
+	*Registry
+}
+
+func (l registryListener) Global(Name gen.WlUint, WlInterface gen.WlString, Version gen.WlUint) {
+	g := Global{Name: uint32(Name), Interface: string(WlInterface), Version: uint32(Version)}
+	l.mu.Lock()
+	l.globals[g.Name] = g
+	watches := l.watches[g.Interface]
+	l.mu.Unlock()
+
+	for _, w := range watches {
+		if w.added != nil {
+			w.added(g)
+		}
+	}
+}
+
+func (l registryListener) GlobalRemove(Name gen.WlUint) {
+	l.mu.Lock()
+	g, ok := l.globals[uint32(Name)]
+	delete(l.globals, uint32(Name))
+	watches := l.watches[g.Interface]
+	l.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, w := range watches {
+		if w.removed != nil {
+			w.removed(g)
+		}
+	}
+}
+
+// Globals returns the globals currently announced, ordered by name.
+func (r *Registry) Globals() []Global {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	globals := make([]Global, 0, len(r.globals))
+	for _, g := range r.globals {
+		globals = append(globals, g)
+	}
+	sort.Slice(globals, func(i, j int) bool { return globals[i].Name < globals[j].Name })
+	return globals
+}
+
+// Find returns the globals of the named interface, ordered by name.
+func (r *Registry) Find(iface string) []Global {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(iface)
+}
+
+// find is Find with mu held.
+func (r *Registry) find(iface string) []Global {
+	var found []Global
+	for _, g := range r.globals {
+		if g.Interface == iface {
+			found = append(found, g)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
+
+// Watch registers callbacks for globals of the named interface, such as
+// outputs and seats being plugged in and out. added is called at once for
+// each matching global already known, and then from the registry's event
+// dispatch whenever one is announced; removed is called when one goes away.
+// Either may be nil.
+func (r *Registry) Watch(iface string, added, removed func(Global)) {
+	// The globals known when the watch is installed are replayed, and only
+	// those: any announced later reach the watch through the listener.
+	r.mu.Lock()
+	r.watches[iface] = append(r.watches[iface], globalWatch{added: added, removed: removed})
+	known := r.find(iface)
+	r.mu.Unlock()
+
+	if added != nil {
+		for _, g := range known {
+			added(g)
+		}
+	}
+}
+
+// Bind binds the global called name, which must implement iface. The object
+// is created with the highest version both sides support: the lower of the
+// global's version and version, the newest version of iface the caller can
+// handle. A version of 0 stands for iface.Version.
+func (r *Registry) Bind(ctx context.Context, name uint32, iface *gen.Interface, version uint32) (*Proxy, error) {
+	r.mu.Lock()
+	g, ok := r.globals[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("Bind: no global %d", name)
+	}
+	if g.Interface != iface.Name {
+		return nil, fmt.Errorf("Bind: global %d is a %s, not a %s", name, g.Interface, iface.Name)
+	}
+
+	if version == 0 || version > iface.Version {
+		version = iface.Version
+	}
+	if g.Version < version {
+		version = g.Version
+	}
+	return r.MarshalConstructorVersioned(ctx, 0, iface, version,
+		gen.WlUint(name), gen.WlString(iface.Name), gen.WlUint(version), gen.WlNewId(0))
+}
+
+// BindFirst binds the first announced global implementing iface, as Bind
+// does.
+func (r *Registry) BindFirst(ctx context.Context, iface *gen.Interface, version uint32) (*Proxy, error) {
+	found := r.Find(iface.Name)
+	if len(found) == 0 {
+		return nil, fmt.Errorf("BindFirst: no %s global", iface.Name)
+	}
+	return r.Bind(ctx, found[0].Name, iface, version)
+}
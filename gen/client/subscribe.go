@@ -0,0 +1,188 @@
+package client
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// An Event is an event delivered to a Subscription. Value holds the
+// generated struct for the event, such as gen.WlPointerMotionEvent.
+type Event struct {
+	Proxy *Proxy
+	Value interface{}
+}
+
+// Overflow selects what happens to an event that arrives while a
+// subscription's buffer is full.
+type Overflow int
+
+const (
+	// Block makes the dispatching goroutine wait until the subscriber makes
+	// room. Back-pressure propagates to the queue, and through it to the
+	// connection: the subscriber must not be the goroutine dispatching the
+	// queue.
+	Block Overflow = iota
+	// DropNewest discards the event that did not fit.
+	DropNewest
+	// DropOldest discards the oldest buffered event to make room, which
+	// suits streams like pointer motion where only the latest value matters.
+	DropOldest
+)
+
+// A Subscription delivers events on a channel as an alternative to
+// implementing handler methods. Events are delivered when the queue of the
+// object they are addressed to is dispatched, after the object's handler, if
+// any, has run.
+//
+// File descriptors in an event belong to the subscriber that receives it;
+// each subscriber gets its own duplicate, and those of dropped events are
+// closed.
+type Subscription struct {
+	// C carries the events. It is closed by Close.
+	C <-chan Event
+
+	c        chan Event
+	overflow Overflow
+	dropped  uint64
+
+	display *Display
+	proxy   *Proxy
+	iface   *gen.Interface
+
+	mu        sync.Mutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (d *Display) newSubscription(size int, overflow Overflow) *Subscription {
+	// An unbuffered channel has no oldest event to drop, so DropOldest
+	// would block like Block; both drop policies buffer at least one.
+	if size < 1 && overflow != Block {
+		size = 1
+	}
+	c := make(chan Event, size)
+	return &Subscription{C: c, c: c, overflow: overflow, display: d, done: make(chan struct{})}
+}
+
+// Subscribe delivers p's events on a channel buffering up to size events.
+// With DropNewest and DropOldest, size is at least 1.
+func (p *Proxy) Subscribe(size int, overflow Overflow) *Subscription {
+	d := p.display
+	s := d.newSubscription(size, overflow)
+	s.proxy = p
+	d.mu.Lock()
+	p.subs = append(p.subs, s)
+	d.mu.Unlock()
+	return s
+}
+
+// SubscribeInterface delivers the events of every object implementing iface
+// on a channel buffering up to size events, as Subscribe does.
+func (d *Display) SubscribeInterface(iface *gen.Interface, size int, overflow Overflow) *Subscription {
+	s := d.newSubscription(size, overflow)
+	s.iface = iface
+	d.mu.Lock()
+	if d.ifaceSubs == nil {
+		d.ifaceSubs = map[*gen.Interface][]*Subscription{}
+	}
+	d.ifaceSubs[iface] = append(d.ifaceSubs[iface], s)
+	d.mu.Unlock()
+	return s
+}
+
+// Dropped returns the number of events discarded because the buffer was
+// full.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close stops delivery and closes C. Events still buffered in C are left
+// for the subscriber to drain.
+func (s *Subscription) Close() {
+	d := s.display
+	d.mu.Lock()
+	if s.proxy != nil {
+		s.proxy.subs = removeSub(s.proxy.subs, s)
+	} else {
+		d.ifaceSubs[s.iface] = removeSub(d.ifaceSubs[s.iface], s)
+	}
+	d.mu.Unlock()
+
+	// Wake up a blocked deliver before closing the channel under it.
+	s.closeOnce.Do(func() { close(s.done) })
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.c)
+	}
+	s.mu.Unlock()
+}
+
+func removeSub(subs []*Subscription, s *Subscription) []*Subscription {
+	for i, v := range subs {
+		if v == s {
+			return append(subs[:i:i], subs[i+1:]...)
+		}
+	}
+	return subs
+}
+
+// deliver queues ev according to the overflow policy. It reports whether ev
+// was delivered; if not the caller must release its file descriptors.
+func (s *Subscription) deliver(ev Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+
+	select {
+	case s.c <- ev:
+		return true
+	default:
+	}
+
+	switch s.overflow {
+	case Block:
+		select {
+		case s.c <- ev:
+			return true
+		case <-s.done:
+			return false
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.c <- ev:
+				return true
+			case old := <-s.c:
+				atomic.AddUint64(&s.dropped, 1)
+				closeEventFDs(old.Value)
+			}
+		}
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	return false
+}
+
+// eventValue builds the generated event struct from decoded arguments.
+func eventValue(msg *gen.Message, args []interface{}) interface{} {
+	v := reflect.New(reflect.TypeOf(msg.Event)).Elem()
+	for i, a := range args {
+		v.Field(i).Set(reflect.ValueOf(a))
+	}
+	return v.Interface()
+}
+
+func closeEventFDs(value interface{}) {
+	v := reflect.ValueOf(value)
+	for i := 0; i < v.NumField(); i++ {
+		if fd, ok := v.Field(i).Interface().(gen.WlFd); ok {
+			closeArgFDs([]interface{}{fd})
+		}
+	}
+}
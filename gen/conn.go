@@ -0,0 +1,220 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// A Conn is a wayland connection that reassembles messages split across
+// reads and queues received file descriptors until the messages that carry
+// them are decoded.
+//
+// Writes may be issued from any goroutine: each batch of messages and its
+// file descriptors is written atomically, in the order the writes acquire
+// the connection. Reads must come from one goroutine at a time.
+type Conn struct {
+	c   *net.UnixConn
+	wmu sync.Mutex
+
+	// in holds received bytes in in[start:end]. A message is at most
+	// maxMsgSize bytes, so after compaction there is always room to read at
+	// least that much.
+	in         [2 * maxMsgSize]byte
+	start, end int
+	fds        []int
+}
+
+func NewConn(c *net.UnixConn) *Conn {
+	return &Conn{c: c}
+}
+
+// UnixConn returns the underlying socket.
+func (c *Conn) UnixConn() *net.UnixConn {
+	return c.c
+}
+
+// ReadMessages reads from the socket and appends every complete message
+// received so far to msgs. The messages' Data alias the connection's buffer
+// and are only valid until the next call to ReadMessages or
+// TryReadMessages. It gives up when ctx is done, in which case nothing is
+// consumed from the socket.
+func (c *Conn) ReadMessages(ctx context.Context, msgs []WlMessage) ([]WlMessage, error) {
+	c.compact()
+
+	oob := oobPool.Get().(*[oobSize]byte)
+	defer oobPool.Put(oob)
+	done := applyContext(ctx, c.c, false)
+	n, oobn, _, _, err := c.c.ReadMsgUnix(c.in[c.end:], oob[:])
+	if err = done(err); err != nil {
+		return msgs, err
+	}
+	return c.received(msgs, n, oob[:oobn])
+}
+
+// TryReadMessages is like ReadMessages but never blocks: if there is nothing
+// to read it returns msgs unchanged and a nil error. It is meant for event
+// loops that poll the socket themselves.
+func (c *Conn) TryReadMessages(msgs []WlMessage) ([]WlMessage, error) {
+	c.compact()
+
+	raw, err := c.c.SyscallConn()
+	if err != nil {
+		return msgs, err
+	}
+	oob := oobPool.Get().(*[oobSize]byte)
+	defer oobPool.Put(oob)
+	var n, oobn int
+	var rerr error
+	err = raw.Read(func(fd uintptr) bool {
+		n, oobn, _, _, rerr = unix.Recvmsg(int(fd), c.in[c.end:], oob[:], unix.MSG_DONTWAIT|unix.MSG_CMSG_CLOEXEC)
+		return true
+	})
+	if err == nil {
+		err = rerr
+	}
+	if err == unix.EAGAIN || err == unix.EINTR {
+		return msgs, nil
+	}
+	if err != nil {
+		return msgs, err
+	}
+	return c.received(msgs, n, oob[:oobn])
+}
+
+// compact moves any partial message to the start of the buffer.
+func (c *Conn) compact() {
+	c.end = copy(c.in[:], c.in[c.start:c.end])
+	c.start = 0
+}
+
+// received takes in n bytes read into the buffer and the control data that
+// came with them, and appends the messages completed so far to msgs.
+func (c *Conn) received(msgs []WlMessage, n int, oob []byte) ([]WlMessage, error) {
+	var err error
+	c.fds, err = parseFDs(c.fds, oob)
+	if err != nil {
+		return msgs, err
+	}
+	if n == 0 {
+		return msgs, io.EOF
+	}
+	c.end += n
+
+	for c.end-c.start >= headerSize {
+		head, _, _ := parseHeader(c.in[c.start:c.end])
+		if int(head.Size) < headerSize || int(head.Size) > maxMsgSize {
+			return msgs, errors.New("ReadMessages: invalid message size")
+		}
+		if int(head.Size) > c.end-c.start {
+			break
+		}
+		msg, _, _ := parseOneMessage(c.in[c.start:c.end])
+		msgs = append(msgs, msg)
+		c.start += int(head.Size)
+	}
+	return msgs, nil
+}
+
+// Unmarshal decodes the arguments of msg according to m, taking any file
+// descriptors it carries from the connection's queue.
+func (c *Conn) Unmarshal(m *Message, msg WlMessage) ([]interface{}, error) {
+	args, fds, err := UnmarshalArgs(m, msg.Data, c.fds)
+	c.fds = fds
+	return args, err
+}
+
+// PendingFDs returns the number of received file descriptors not yet
+// taken by Unmarshal.
+func (c *Conn) PendingFDs() int {
+	return len(c.fds)
+}
+
+// DiscardFDs closes the next n queued file descriptors, which belong to a
+// message that is being dropped.
+func (c *Conn) DiscardFDs(n int) error {
+	if n > len(c.fds) {
+		return errors.New("DiscardFDs: missing file descriptors")
+	}
+	closeFDs(c.fds[:n])
+	c.fds = c.fds[n:]
+	return nil
+}
+
+// TakeFDs removes the next n queued file descriptors and returns them; the
+// caller must close them. It is for proxies that pass messages on without
+// decoding them.
+func (c *Conn) TakeFDs(n int) ([]int, error) {
+	if n > len(c.fds) {
+		return nil, errors.New("TakeFDs: missing file descriptors")
+	}
+	fds := c.fds[:n:n]
+	c.fds = c.fds[n:]
+	return fds, nil
+}
+
+// WriteMessage sends msg and its file descriptors.
+func (c *Conn) WriteMessage(ctx context.Context, msg WlMessage, fds []int) error {
+	return c.WriteMessages(ctx, []WlMessage{msg}, fds)
+}
+
+// WriteMessages sends msgs and their file descriptors in a single batch.
+func (c *Conn) WriteMessages(ctx context.Context, msgs []WlMessage, fds []int) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return SendMsg(ctx, c.c, &WlWireMessage{Messages: msgs, FDs: fds})
+}
+
+// AppendMessages appends the wire encoding of msgs to bs.
+func AppendMessages(bs []byte, msgs ...WlMessage) []byte {
+	n := len(bs)
+	size := messagesSize(msgs)
+	if cap(bs)-n < size {
+		nbs := make([]byte, n, n+size)
+		copy(nbs, bs)
+		bs = nbs
+	}
+	marshMessages(bs[n:n+size], msgs)
+	return bs[:n+size]
+}
+
+// TryWrite writes as much of bs as the socket takes without blocking,
+// sending fds along with the first byte, and returns the number of bytes
+// written. A full socket is not an error: nothing is written, fds
+// included, and n is 0. It is meant for event loops that buffer what the
+// socket does not take, and is serialized with WriteMessages.
+func (c *Conn) TryWrite(bs []byte, fds []int) (n int, err error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	raw, err := c.c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	oob := marshFDs(fds)
+	var werr error
+	err = raw.Write(func(fd uintptr) bool {
+		n, werr = unix.SendmsgN(int(fd), bs, oob, nil, unix.MSG_DONTWAIT|unix.MSG_NOSIGNAL)
+		return true
+	})
+	if err == nil {
+		err = werr
+	}
+	if err == unix.EAGAIN || err == unix.EINTR {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close closes the socket and any file descriptors still queued.
+func (c *Conn) Close() error {
+	closeFDs(c.fds)
+	c.fds = nil
+	return c.c.Close()
+}
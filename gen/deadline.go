@@ -0,0 +1,63 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// A time in the past, used to make blocked socket calls return at once.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// applyContext maps ctx onto conn's read or write deadline for the duration
+// of one blocking call: ctx's deadline becomes the socket deadline, and
+// cancelling ctx moves the deadline into the past so the call returns. The
+// returned function must be called with the call's error once it returns. It
+// clears the deadline again and replaces a resulting timeout with ctx's
+// error.
+func applyContext(ctx context.Context, conn *net.UnixConn, write bool) func(error) error {
+	if ctx.Done() == nil {
+		return noContext
+	}
+	setDeadline := conn.SetReadDeadline
+	if write {
+		setDeadline = conn.SetWriteDeadline
+	}
+	if d, ok := ctx.Deadline(); ok {
+		setDeadline(d)
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			setDeadline(aLongTimeAgo)
+		case <-stop:
+		}
+	}()
+
+	return func(err error) error {
+		close(stop)
+		<-stopped
+		setDeadline(time.Time{})
+		if err == nil {
+			return nil
+		}
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
+		// The socket deadline can expire just before ctx's own timer does.
+		if d, ok := ctx.Deadline(); ok && errors.Is(err, os.ErrDeadlineExceeded) && !time.Now().Before(d) {
+			return context.DeadlineExceeded
+		}
+		return err
+	}
+}
+
+func noContext(err error) error {
+	return err
+}
@@ -0,0 +1,73 @@
+package gen
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var argTypes = map[ArgType]reflect.Type{
+	ArgInt:    reflect.TypeOf(WlInt(0)),
+	ArgUint:   reflect.TypeOf(WlUint(0)),
+	ArgFixed:  reflect.TypeOf(WlFixed(0)),
+	ArgString: reflect.TypeOf(WlString("")),
+	ArgObject: reflect.TypeOf(WlObject(0)),
+	ArgNewId:  reflect.TypeOf(WlNewId(0)),
+	ArgArray:  reflect.TypeOf(WlArray(nil)),
+	ArgFd:     reflect.TypeOf(WlFd(0)),
+}
+
+// BindMethods looks up the methods of h that handle msgs: for each message,
+// the method named and typed as in the generated interfaces. Messages h has
+// no method for get the zero Value. A method with a message's name but the
+// wrong signature is an error.
+//
+// A new_id argument with no fixed interface, as in wl_registry.bind, is
+// passed as three parameters the way it is sent: the interface name
+// (WlString), the version (WlUint) and the new ID.
+func BindMethods(h interface{}, msgs []Message) ([]reflect.Value, error) {
+	v := reflect.ValueOf(h)
+	methods := make([]reflect.Value, len(msgs))
+	for i := range msgs {
+		msg := &msgs[i]
+		m := v.MethodByName(msg.GoName())
+		if !m.IsValid() {
+			continue
+		}
+		if !matchArgs(m.Type(), msg.Args) {
+			return nil, fmt.Errorf("BindMethods: %T.%s does not match %s", h, msg.GoName(), msg.Name)
+		}
+		methods[i] = m
+	}
+	return methods, nil
+}
+
+func matchArgs(t reflect.Type, args []Arg) bool {
+	if t.NumOut() != 0 {
+		return false
+	}
+	var want []reflect.Type
+	for _, a := range args {
+		if a.Type == ArgNewId && a.Interface == "" {
+			want = append(want, argTypes[ArgString], argTypes[ArgUint])
+		}
+		want = append(want, argTypes[a.Type])
+	}
+	if t.NumIn() != len(want) {
+		return false
+	}
+	for i, w := range want {
+		if t.In(i) != w {
+			return false
+		}
+	}
+	return true
+}
+
+// CallMethod calls a method found by BindMethods with decoded arguments.
+func CallMethod(m reflect.Value, args []interface{}) {
+	in := make([]reflect.Value, len(args))
+	for i, v := range args {
+		in[i] = reflect.ValueOf(v)
+	}
+	m.Call(in)
+}
@@ -0,0 +1,109 @@
+package gen
+
+import "strings"
+
+// An ArgType identifies the wire encoding of a message argument. The values
+// are the signature characters used by libwayland.
+type ArgType byte
+
+const (
+	ArgInt    ArgType = 'i'
+	ArgUint   ArgType = 'u'
+	ArgFixed  ArgType = 'f'
+	ArgString ArgType = 's'
+	ArgObject ArgType = 'o'
+	ArgNewId  ArgType = 'n'
+	ArgArray  ArgType = 'a'
+	ArgFd     ArgType = 'h'
+)
+
+// Arg describes one argument of a request or event. Interface names the
+// interface of object and new_id arguments, when the protocol specifies one.
+type Arg struct {
+	Name      string
+	Type      ArgType
+	Interface string
+	AllowNull bool
+}
+
+// Message describes a request or event. Its opcode is its index in the
+// Requests or Events of its Interface. For events, Event is the zero value of
+// the generated struct holding the event's arguments, whose fields are in
+// the same order as Args.
+type Message struct {
+	Name       string
+	Since      uint32
+	Destructor bool
+	Args       []Arg
+	Event      interface{}
+}
+
+// NumFDs returns the number of file descriptors the message carries.
+func (m *Message) NumFDs() int {
+	n := 0
+	for _, v := range m.Args {
+		if v.Type == ArgFd {
+			n++
+		}
+	}
+	return n
+}
+
+// GoName returns the name of the method the generated interfaces use for m.
+func (m *Message) GoName() string {
+	subs := strings.Split(m.Name, "_")
+	for i, v := range subs {
+		if v != "" {
+			subs[i] = strings.ToUpper(v[:1]) + v[1:]
+		}
+	}
+	return strings.Join(subs, "")
+}
+
+type EnumEntry struct {
+	Name  string
+	Value uint32
+}
+
+type Enum struct {
+	Name    string
+	Entries []EnumEntry
+}
+
+// Interface describes a protocol interface as specified in the protocol XML.
+type Interface struct {
+	Name     string
+	Version  uint32
+	Requests []Message
+	Events   []Message
+	Enums    []Enum
+}
+
+// EnumEntryName returns the name of the entry with the given value in the
+// named enum, or "" if there is none. The error codes of an interface are
+// its "error" enum.
+func (i *Interface) EnumEntryName(enum string, value uint32) string {
+	for _, e := range i.Enums {
+		if e.Name != enum {
+			continue
+		}
+		for _, v := range e.Entries {
+			if v.Value == value {
+				return v.Name
+			}
+		}
+	}
+	return ""
+}
+
+var interfaces = map[string]*Interface{}
+
+func registerInterface(iface *Interface) {
+	interfaces[iface.Name] = iface
+}
+
+// LookupInterface returns the description of the named interface, or nil if
+// it is not known.
+func LookupInterface(name string) *Interface {
+	return interfaces[name]
+}
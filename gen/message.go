@@ -1,35 +1,42 @@
 package gen
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net"
-	"reflect"
+	"sync"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
 )
 
+// The largest message the wire protocol allows, and the size of the buffers
+// used to receive and send batches of messages.
+const (
+	maxMsgSize = 4096
+	maxFDs     = 28
+	oobSize    = unix.SizeofCmsghdr + maxFDs*4
+)
+
 type WlHeader struct {
 	Id   uint32
 	Op   uint16
 	Size uint16
 }
 
+const headerSize = int(unsafe.Sizeof(WlHeader{}))
+
 func parseHeader(bs []byte) (WlHeader, []byte, error) {
-	if len(bs) < 8 {
+	if len(bs) < headerSize {
 		return WlHeader{}, nil, errors.New("ReadHeader: not enough data")
 	}
 
-	headerBytes := bs[:8]
-	sliceHeader := (*reflect.SliceHeader)(unsafe.Pointer(&headerBytes))
-
-	return *(*WlHeader)(unsafe.Pointer(sliceHeader.Data)), bs[8:], nil
+	return *(*WlHeader)(unsafe.Pointer(&bs[0])), bs[headerSize:], nil
 }
 
-func marshHeader(head WlHeader) []byte {
-	bs := make([]byte, 8)
-	copy(bs, (*(*[8]byte)(unsafe.Pointer(&head)))[:])
-	return bs
+func putHeader(bs []byte, head WlHeader) {
+	*(*WlHeader)(unsafe.Pointer(&bs[0])) = head
 }
 
 type WlMessage struct {
@@ -37,82 +44,139 @@ type WlMessage struct {
 	Data []byte
 }
 
+// A WlWireMessage is a batch of messages and file descriptors as sent or
+// received by a single sendmsg/recvmsg call.
+//
+// Messages returned by ReadMsg are decoded in place: their Data slices alias
+// a pooled receive buffer. Call Release once the batch has been handled to
+// hand that buffer back for reuse. Anything that needs to outlive the batch
+// must be copied out first. Forgetting to call Release is safe; the buffer is
+// simply left to the garbage collector.
 type WlWireMessage struct {
 	Messages []WlMessage
 	FDs      []int
+
+	buf *[maxMsgSize]byte
 }
 
+// Release returns the receive buffer backing the batch to the pool. Neither
+// the batch nor the Data of its messages may be used afterwards. The FDs are
+// not closed; they belong to whoever consumed them.
+func (w *WlWireMessage) Release() {
+	if w.buf == nil {
+		return
+	}
+	bufPool.Put(w.buf)
+	w.buf = nil
+	for i := range w.Messages {
+		w.Messages[i].Data = nil
+	}
+	w.Messages = w.Messages[:0]
+	w.FDs = w.FDs[:0]
+	wirePool.Put(w)
+}
+
+// ErrPartialWrite is wrapped, along with the cause, in the error of a send
+// that failed after writing part of its batch. The peer is left with half a
+// message, so the connection cannot be used any more. A send that fails
+// without writing anything returns the cause alone.
+var ErrPartialWrite = errors.New("partial write")
+
+var (
+	bufPool = sync.Pool{
+		New: func() interface{} { return new([maxMsgSize]byte) },
+	}
+	oobPool = sync.Pool{
+		New: func() interface{} { return new([oobSize]byte) },
+	}
+	wirePool = sync.Pool{
+		New: func() interface{} { return new(WlWireMessage) },
+	}
+)
+
+// parseOneMessage decodes the message at the start of bs. The returned
+// message's Data aliases bs.
 func parseOneMessage(bs []byte) (msg WlMessage, rest []byte, err error) {
 	msg.WlHeader, _, err = parseHeader(bs)
 	if err != nil {
 		return
 	}
-	if len(bs) < int(msg.Size) {
+	if int(msg.Size) < headerSize || len(bs) < int(msg.Size) {
 		err = errors.New("ReadMessage: actual size does not match header size")
 		return
 	}
-	msg.Data = make([]byte, msg.Size-8)
-	copy(msg.Data, bs[8:])
+	msg.Data = bs[headerSize:msg.Size:msg.Size]
 	rest = bs[msg.Size:]
 	return
 }
 
-func marshOneMessage(msg WlMessage) []byte {
-	bs := make([]byte, msg.Size)
-	copy(bs, marshHeader(msg.WlHeader))
-	copy(bs[8:], msg.Data)
-	return bs
+// putMessage encodes msg into the start of bs and returns the number of bytes
+// written. bs must have room for msg.Size bytes.
+func putMessage(bs []byte, msg WlMessage) int {
+	putHeader(bs, msg.WlHeader)
+	copy(bs[headerSize:msg.Size], msg.Data)
+	return int(msg.Size)
 }
 
-func parseMessages(bs []byte) ([]WlMessage, error) {
-	var msgs []WlMessage
+// parseMessages appends the messages in bs to msgs.
+func parseMessages(msgs []WlMessage, bs []byte) ([]WlMessage, error) {
 	var msg WlMessage
 	var err error
-	for {
-		if len(bs) == 0 {
-			return msgs, nil
-		}
+	for len(bs) != 0 {
 		msg, bs, err = parseOneMessage(bs)
 		if err != nil {
-			return nil, err
+			return msgs, err
 		}
 		msgs = append(msgs, msg)
 	}
+	return msgs, nil
 }
 
-func marshMessages(msgs []WlMessage) []byte {
-	var bs []byte
+func messagesSize(msgs []WlMessage) int {
+	n := 0
 	for _, v := range msgs {
-		bs = append(bs, marshOneMessage(v)...)
+		n += int(v.Size)
 	}
-	return bs
+	return n
 }
 
-func parseFDs(oob []byte) ([]int, error) {
-	scms, err := unix.ParseSocketControlMessage(oob)
-	if err != nil {
-		return nil, err
+// marshMessages encodes msgs into bs, which must be at least
+// messagesSize(msgs) long.
+func marshMessages(bs []byte, msgs []WlMessage) []byte {
+	n := 0
+	for _, v := range msgs {
+		n += putMessage(bs[n:], v)
 	}
-	fdss := make([][]int, len(scms))
-	total := 0
-	for i, v := range scms {
-		fdss[i], err = unix.ParseUnixRights(&v)
-		if err != nil {
-			return nil, err
+	return bs[:n]
+}
+
+// parseFDs appends the file descriptors carried in oob to fds.
+func parseFDs(fds []int, oob []byte) ([]int, error) {
+	for len(oob) >= unix.SizeofCmsghdr {
+		h := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0]))
+		if int(h.Len) < unix.SizeofCmsghdr || int(h.Len) > len(oob) {
+			return fds, errors.New("parseFDs: invalid control message length")
 		}
-		total += len(fdss[i])
-	}
-	fds := make([]int, 0, total)
-	for _, v := range fdss {
-		for _, fd := range v {
-			fds = append(fds, fd)
+		if h.Level == unix.SOL_SOCKET && h.Type == unix.SCM_RIGHTS {
+			data := oob[unix.CmsgLen(0):h.Len]
+			for ; len(data) >= 4; data = data[4:] {
+				fds = append(fds, int(*(*int32)(unsafe.Pointer(&data[0]))))
+			}
 		}
+		next := unix.CmsgSpace(int(h.Len) - unix.CmsgLen(0))
+		if next > len(oob) {
+			break
+		}
+		oob = oob[next:]
 	}
 
 	return fds, nil
 }
 
 func marshFDs(fds []int) []byte {
+	if len(fds) == 0 {
+		return nil
+	}
 	return unix.UnixRights(fds...)
 }
 
@@ -127,37 +191,84 @@ type (
 	WlFd     uintptr
 )
 
-type unixMsg struct {
-	pkt, oob []byte
-}
+// ReadMsg receives one batch of messages from conn. The batch is decoded in
+// place into a pooled buffer; see WlWireMessage.Release. ReadMsg gives up
+// when ctx is done.
+func ReadMsg(ctx context.Context, conn *net.UnixConn) (*WlWireMessage, error) {
+	buf := bufPool.Get().(*[maxMsgSize]byte)
+	oob := oobPool.Get().(*[oobSize]byte)
+	defer oobPool.Put(oob)
 
-func ReadMsg(conn *net.UnixConn) (*WlWireMessage, error) {
-	pkt := make([]byte, 4096)
-	oob := make([]byte, 4096)
-	n, oobn, _, _, err := conn.ReadMsgUnix(pkt, oob)
-	if err != nil {
+	done := applyContext(ctx, conn, false)
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf[:], oob[:])
+	if err = done(err); err != nil {
+		bufPool.Put(buf)
 		return nil, err
 	}
-	pkt = pkt[:n]
-	oob = oob[:oobn]
-	msgs, err := parseMessages(pkt)
+
+	wmsg := wirePool.Get().(*WlWireMessage)
+	wmsg.buf = buf
+	wmsg.FDs, err = parseFDs(wmsg.FDs[:0], oob[:oobn])
 	if err != nil {
+		closeFDs(wmsg.FDs)
+		wmsg.Release()
 		return nil, err
 	}
-	fds, err := parseFDs(oob)
+	wmsg.Messages, err = parseMessages(wmsg.Messages[:0], buf[:n])
 	if err != nil {
+		closeFDs(wmsg.FDs)
+		wmsg.Release()
 		return nil, err
 	}
-	return &WlWireMessage{
-		Messages: msgs,
-		FDs:      fds,
-	}, nil
+	return wmsg, nil
 }
 
-func SendMsg(conn *net.UnixConn, wmsg *WlWireMessage) error {
-	_, _, err := conn.WriteMsgUnix(marshMessages(wmsg.Messages), marshFDs(wmsg.FDs), nil)
-	if err != nil {
+// SendMsg sends wmsg as a single batch. Batches that fit within the
+// protocol's buffer size are encoded into a pooled buffer. SendMsg gives up
+// when ctx is done.
+//
+// SendMsg does not serialize concurrent callers; use a Conn to send from
+// several goroutines.
+func SendMsg(ctx context.Context, conn *net.UnixConn, wmsg *WlWireMessage) error {
+	var bs []byte
+	if size := messagesSize(wmsg.Messages); size <= maxMsgSize {
+		buf := bufPool.Get().(*[maxMsgSize]byte)
+		defer bufPool.Put(buf)
+		bs = buf[:size]
+	} else {
+		bs = make([]byte, size)
+	}
+
+	done := applyContext(ctx, conn, true)
+	bs = marshMessages(bs, wmsg.Messages)
+	n, err := writeAll(conn, bs, marshFDs(wmsg.FDs))
+	if err = done(err); err != nil {
+		if n > 0 {
+			return fmt.Errorf("SendMsg: %w after %d of %d bytes: %w", ErrPartialWrite, n, len(bs), err)
+		}
 		return err
 	}
 	return nil
 }
+
+// writeAll writes bs, sending oob along with its first byte, and returns how
+// much it wrote. A stream socket may accept only part of a batch; the rest
+// is written by further calls so the batch is never cut short.
+func writeAll(conn *net.UnixConn, bs, oob []byte) (int, error) {
+	written := 0
+	for len(bs) > written {
+		n, _, err := conn.WriteMsgUnix(bs[written:], oob, nil)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		oob = nil
+	}
+	return written, nil
+}
+
+func closeFDs(fds []int) {
+	for _, fd := range fds {
+		unix.Close(fd)
+	}
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+	"golang.org/x/sys/unix"
+)
+
+// Credentials identify the process at the other end of a client connection,
+// as reported by the kernel when it connected.
+type Credentials struct {
+	Pid int32
+	Uid uint32
+	Gid uint32
+}
+
+// PeerCredentials returns the SO_PEERCRED credentials of conn's peer.
+func PeerCredentials(conn *net.UnixConn) (Credentials, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return Credentials{}, err
+	}
+	var cred *unix.Ucred
+	cerr := raw.Control(func(fd uintptr) {
+		cred, err = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if cerr != nil {
+		return Credentials{}, cerr
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+	return Credentials{Pid: cred.Pid, Uid: cred.Uid, Gid: cred.Gid}, nil
+}
+
+// A Client is a connection from a client to the Display. It owns the
+// resources the client created or was given.
+type Client struct {
+	display    *Display
+	conn       *gen.Conn
+	source     *EventSource
+	creds      Credentials
+	objects    resourceMap
+	displayRes *Resource
+	registries []*Resource
+	msgs       []gen.WlMessage
+
+	// out holds the events the socket has not taken yet, and outBytes
+	// their size.
+	out      []outChunk
+	outBytes int
+	wbuf     []byte
+
+	limits Limits
+	// tokens is the request rate limit's bucket, refilled since
+	// lastRequest. second and secondCount count the requests in the
+	// current second, for reporting.
+	tokens      float64
+	lastRequest time.Time
+	second      time.Time
+	secondCount int
+
+	errored          bool
+	disconnecting    bool
+	destroyed        bool
+	destroyListeners []func(*Client)
+	userData         interface{}
+}
+
+// Display returns the display the client is connected to.
+func (c *Client) Display() *Display {
+	return c.display
+}
+
+// Credentials returns the credentials of the client process.
+func (c *Client) Credentials() Credentials {
+	return c.creds
+}
+
+// UserData returns the value set with SetUserData.
+func (c *Client) UserData() interface{} {
+	return c.userData
+}
+
+// SetUserData attaches an arbitrary value to the client.
+func (c *Client) SetUserData(v interface{}) {
+	c.userData = v
+}
+
+// AddDestroyListener registers f to be called when the client disconnects
+// or is disconnected, after its resources have been destroyed.
+func (c *Client) AddDestroyListener(f func(*Client)) {
+	c.destroyListeners = append(c.destroyListeners, f)
+}
+
+// Resource returns the live resource with the given ID, or nil.
+func (c *Client) Resource(id uint32) *Resource {
+	return c.objects.lookup(id)
+}
+
+// Resources returns every live resource of the client.
+func (c *Client) Resources() []*Resource {
+	return c.objects.all()
+}
+
+// NewResource creates a resource for the client. An id of 0 allocates an ID
+// from the server's range, for objects announced through a new_id event
+// argument; otherwise id is the new_id the client passed in a request.
+//
+// Objects created through another object take its version, which is what
+// the client's proxy has: a wl_pointer from a version 4 wl_seat is version
+// 4, even though wl_pointer itself only goes up to 3.
+func (c *Client) NewResource(id uint32, iface *gen.Interface, version uint32) (*Resource, error) {
+	if c.destroyed {
+		return nil, fmt.Errorf("NewResource: client has been destroyed")
+	}
+	if !c.checkObjects() {
+		return nil, fmt.Errorf("NewResource: client exceeded its object limit")
+	}
+	r := &Resource{id: id, iface: iface, version: version, client: c}
+	if id == 0 {
+		c.objects.insertNew(r)
+		return r, nil
+	}
+	if err := c.objects.insertAt(r); err != nil {
+		return nil, fmt.Errorf("NewResource: %v", err)
+	}
+	return r, nil
+}
+
+// PostError sends a fatal protocol error about object objectId to the
+// client, which is disconnected once the request being handled is done, or
+// when the event loop next goes idle if the error did not come from a
+// request. Only the first error is sent.
+func (c *Client) PostError(objectId, code uint32, format string, args ...interface{}) {
+	if c.errored || c.destroyed {
+		return
+	}
+	c.errored = true
+	c.displayRes.PostEvent(0, gen.WlObject(objectId), gen.WlUint(code), gen.WlString(fmt.Sprintf(format, args...)))
+	c.display.loop.AddIdle(c.Destroy)
+}
+
+// Destroy disconnects the client, destroying all its resources.
+func (c *Client) Destroy() {
+	if c.destroyed {
+		return
+	}
+	c.destroyed = true
+	// A last try at events still buffered, such as a protocol error.
+	c.flush()
+	c.source.Remove()
+	c.conn.Close()
+	for _, ch := range c.out {
+		closeFDs(ch.fds)
+	}
+	c.out, c.outBytes = nil, 0
+	for _, r := range c.objects.all() {
+		c.objects.remove(r)
+		r.destroy()
+	}
+	for i := len(c.destroyListeners) - 1; i >= 0; i-- {
+		c.destroyListeners[i](c)
+	}
+	c.destroyListeners = nil
+	c.display.removeClient(c)
+}
+
+// An outChunk is part of the events waiting for the socket, with the file
+// descriptors to send along with its first byte. The descriptors are
+// duplicates owned by the chunk.
+type outChunk struct {
+	data []byte
+	fds  []int
+}
+
+// send writes an event to the client's socket without blocking. What the
+// socket does not take is buffered, with duplicates of fds, and written
+// when the socket becomes writable, so the caller may close fds as soon as
+// send returns.
+func (c *Client) send(msg gen.WlMessage, fds []int) error {
+	if c.destroyed || c.disconnecting {
+		return fmt.Errorf("send: client has been disconnected")
+	}
+	c.wbuf = gen.AppendMessages(c.wbuf[:0], msg)
+	bs := c.wbuf
+	if len(c.out) == 0 {
+		n, err := c.conn.TryWrite(bs, fds)
+		if err != nil {
+			return err
+		}
+		if n == len(bs) {
+			return nil
+		}
+		bs = bs[n:]
+		if n > 0 {
+			fds = nil
+		}
+	}
+
+	if max := c.limits.MaxOutgoingBytes; max > 0 && c.outBytes+len(bs) > max {
+		c.exceed(LimitOutgoingBytes, float64(c.outBytes+len(bs)), float64(max))
+		return fmt.Errorf("send: client exceeded its outgoing buffer limit")
+	}
+	owned := make([]int, 0, len(fds))
+	for _, fd := range fds {
+		dup, err := unix.FcntlInt(uintptr(fd), unix.F_DUPFD_CLOEXEC, 0)
+		if err != nil {
+			closeFDs(owned)
+			return err
+		}
+		owned = append(owned, dup)
+	}
+	if n := len(c.out); n > 0 && len(owned) == 0 {
+		c.out[n-1].data = append(c.out[n-1].data, bs...)
+	} else {
+		c.out = append(c.out, outChunk{data: append([]byte(nil), bs...), fds: owned})
+	}
+	c.outBytes += len(bs)
+	if len(c.out) == 1 && c.outBytes == len(bs) {
+		return c.source.Update(EventReadable | EventWritable)
+	}
+	return nil
+}
+
+// flush writes buffered events once the socket is writable again.
+func (c *Client) flush() error {
+	for len(c.out) > 0 {
+		ch := &c.out[0]
+		n, err := c.conn.TryWrite(ch.data, ch.fds)
+		if err != nil || n == 0 {
+			return err
+		}
+		closeFDs(ch.fds)
+		ch.fds = nil
+		ch.data = ch.data[n:]
+		c.outBytes -= n
+		if len(ch.data) > 0 {
+			return nil
+		}
+		c.out[0] = outChunk{}
+		c.out = c.out[1:]
+	}
+	c.out = nil
+	return c.source.Update(EventReadable)
+}
+
+// displayImpl implements the wl_display object of a client.
+type displayImpl struct {
+	client *Client
+}
+
+func (d displayImpl) Sync(Callback gen.WlNewId) {
+	c := d.client
+	cb, err := c.NewResource(uint32(Callback), gen.WlCallbackInterface, 1)
+	if err != nil {
+		return
+	}
+	cb.PostEvent(0, gen.WlUint(c.display.NextSerial()))
+	cb.Destroy()
+}
+
+func (d displayImpl) GetRegistry(Registry gen.WlNewId) {
+	d.client.newRegistry(uint32(Registry))
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+	"golang.org/x/sys/unix"
+)
+
+// Run dispatches the display's event loop until ctx is done or the display
+// is closed, accepting clients on the display's sockets and handling their
+// requests. It returns nil once the display is closed.
+func (d *Display) Run(ctx context.Context) error {
+	for {
+		err := d.loop.Dispatch(ctx)
+		if d.closed {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Invoke runs f on the goroutine dispatching the display's event loop and
+// waits for it to return. It reports whether f was run, which it is not if
+// the display is closed first. It must not be called from that goroutine.
+func (d *Display) Invoke(f func()) bool {
+	done := make(chan struct{})
+	ran := false
+	d.loop.Post(func() {
+		defer close(done)
+		if !d.closed {
+			ran = true
+			f()
+		}
+	})
+	select {
+	case <-done:
+		return ran
+	case <-d.done:
+		return false
+	}
+}
+
+// socketFd returns the file descriptor of a socket, for polling it.
+func socketFd(c syscall.Conn) (int, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+	fd := -1
+	if err := raw.Control(func(f uintptr) { fd = int(f) }); err != nil {
+		return -1, err
+	}
+	return fd, nil
+}
+
+func (d *Display) addAccept(s *Socket) (*EventSource, error) {
+	fd, err := socketFd(s.UnixListener)
+	if err != nil {
+		return nil, err
+	}
+	return d.loop.AddFd(fd, EventReadable, func(int, uint32) { d.accept(s) })
+}
+
+// accept takes a pending connection from s without blocking and makes it a
+// client.
+func (d *Display) accept(s *Socket) {
+	raw, err := s.UnixListener.SyscallConn()
+	if err != nil {
+		return
+	}
+	// A listener's raw conn only supports Control, which is enough since
+	// the accept never blocks.
+	nfd := -1
+	cerr := raw.Control(func(fd uintptr) {
+		nfd, _, err = unix.Accept4(int(fd), unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK)
+	})
+	if cerr != nil || err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(nfd), "wayland-client")
+	conn, err := net.FileConn(f)
+	f.Close()
+	if err != nil {
+		return
+	}
+	if _, err := d.CreateClient(conn.(*net.UnixConn)); err != nil {
+		conn.Close()
+	}
+}
+
+// ready writes buffered events once the client's socket is writable, and
+// handles the requests that have arrived on it. It disconnects the client
+// once it hangs up or has been sent an error.
+func (c *Client) ready(fd int, mask uint32) {
+	if c.disconnecting {
+		return
+	}
+	if mask&EventWritable != 0 {
+		if err := c.flush(); err != nil {
+			c.Destroy()
+			return
+		}
+	}
+	if mask&(EventReadable|EventHangup|EventError) == 0 {
+		return
+	}
+	msgs, err := c.conn.TryReadMessages(c.msgs[:0])
+	c.msgs = msgs[:0]
+	if c.checkPendingFDs() {
+		c.handleMessages(msgs)
+	}
+	if err == nil && len(msgs) == 0 && mask&(EventHangup|EventError) != 0 {
+		err = io.EOF
+	}
+	if err != nil || c.errored && !c.disconnecting {
+		c.Destroy()
+	}
+}
+
+// handleMessages handles a batch of requests, stopping at the first
+// protocol error.
+func (c *Client) handleMessages(msgs []gen.WlMessage) {
+	now := time.Now()
+	for _, msg := range msgs {
+		if c.errored || c.destroyed || !c.takeRequest(now) {
+			return
+		}
+		c.handleRequest(msg)
+	}
+}
+
+func (c *Client) handleRequest(msg gen.WlMessage) {
+	r := c.objects.lookup(msg.Id)
+	if r == nil {
+		c.PostError(1, uint32(gen.WlDisplayInvalidObject), "invalid object %d", msg.Id)
+		return
+	}
+	if int(msg.Op) >= len(r.iface.Requests) {
+		c.PostError(1, uint32(gen.WlDisplayInvalidMethod), "invalid method %d, object %s@%d", msg.Op, r.iface.Name, r.id)
+		return
+	}
+	req := &r.iface.Requests[msg.Op]
+	if req.Since > r.version {
+		c.PostError(1, uint32(gen.WlDisplayInvalidMethod), "invalid method %d (since %d > %d), object %s@%d",
+			msg.Op, req.Since, r.version, r.iface.Name, r.id)
+		return
+	}
+	args, err := c.conn.Unmarshal(req, msg)
+	if err != nil {
+		c.PostError(1, uint32(gen.WlDisplayInvalidMethod), "invalid arguments for %s@%d.%s: %v", r.iface.Name, r.id, req.Name, err)
+		return
+	}
+	if !c.checkArgs(r, req, args) {
+		closeArgFDs(args)
+		return
+	}
+
+	if m := r.methods; m != nil && m[msg.Op].IsValid() {
+		gen.CallMethod(m[msg.Op], args)
+	} else {
+		closeArgFDs(args)
+	}
+	if req.Destructor && !r.destroyed {
+		r.Destroy()
+	}
+}
+
+// checkArgs checks that object arguments name live resources of the right
+// interface and that new_id arguments are free, posting an error if not.
+func (c *Client) checkArgs(r *Resource, req *gen.Message, args []interface{}) bool {
+	i := 0
+	for _, a := range req.Args {
+		if a.Type == gen.ArgNewId && a.Interface == "" {
+			// Interface name and version come first.
+			i += 2
+		}
+		v := args[i]
+		i++
+		switch a.Type {
+		case gen.ArgObject:
+			id := uint32(v.(gen.WlObject))
+			if id == 0 {
+				continue
+			}
+			obj := c.objects.lookup(id)
+			if obj == nil {
+				c.PostError(1, uint32(gen.WlDisplayInvalidObject), "unknown object (%d), message %s.%s", id, r.iface.Name, req.Name)
+				return false
+			}
+			if a.Interface != "" && obj.iface.Name != a.Interface {
+				c.PostError(1, uint32(gen.WlDisplayInvalidObject), "%s@%d is not a %s, message %s.%s",
+					obj.iface.Name, id, a.Interface, r.iface.Name, req.Name)
+				return false
+			}
+		case gen.ArgNewId:
+			id := uint32(v.(gen.WlNewId))
+			if !c.objects.validNewId(id) {
+				c.PostError(1, uint32(gen.WlDisplayInvalidObject), "invalid new id %d, message %s.%s", id, r.iface.Name, req.Name)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func closeFDs(fds []int) {
+	for _, fd := range fds {
+		unix.Close(fd)
+	}
+}
+
+func closeArgFDs(args []interface{}) {
+	for _, v := range args {
+		if fd, ok := v.(gen.WlFd); ok {
+			unix.Close(int(fd))
+		}
+	}
+}
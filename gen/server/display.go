@@ -0,0 +1,174 @@
+package server
+
+import (
+	"net"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// A Display is a compositor's side of the protocol: it owns the display
+// sockets and the clients that connect through them.
+//
+// Like libwayland's, a Display is single threaded. It runs on an EventLoop:
+// requests are handled on the goroutine dispatching the loop, normally by
+// calling Run, and the Display, its clients and their resources may only be
+// used from that goroutine, that is from loop callbacks, request handlers
+// and functions passed to Invoke.
+type Display struct {
+	loop    *EventLoop
+	sockets []*Socket
+	accepts []*EventSource
+	clients []*Client
+	serial  uint32
+
+	globals      []*Global
+	nextGlobal   uint32
+	globalFilter GlobalFilter
+
+	clientListeners []func(*Client)
+
+	limits    Limits
+	limitFunc LimitFunc
+
+	closed bool
+	done   chan struct{}
+}
+
+func NewDisplay() (*Display, error) {
+	loop, err := NewEventLoop()
+	if err != nil {
+		return nil, err
+	}
+	return &Display{loop: loop, done: make(chan struct{}), limits: DefaultLimits, limitFunc: logLimit}, nil
+}
+
+// EventLoop returns the loop the display runs on. Compositors add their own
+// timers, signals and file descriptors to it.
+func (d *Display) EventLoop() *EventLoop {
+	return d.loop
+}
+
+// AddSocket listens on the display socket called name, as Listen does.
+func (d *Display) AddSocket(name string) error {
+	s, err := Listen(name)
+	if err != nil {
+		return err
+	}
+	if err := d.AddListener(s); err != nil {
+		s.Close()
+		return err
+	}
+	return nil
+}
+
+// AddSocketAuto listens on the first free wayland-N socket and returns its
+// name, for the compositor to pass to its clients in WAYLAND_DISPLAY.
+func (d *Display) AddSocketAuto() (string, error) {
+	s, err := ListenAuto()
+	if err != nil {
+		return "", err
+	}
+	if err := d.AddListener(s); err != nil {
+		s.Close()
+		return "", err
+	}
+	return s.Name(), nil
+}
+
+// AddListener accepts clients on a socket opened elsewhere, such as one from
+// FileListener or FDListener.
+func (d *Display) AddListener(s *Socket) error {
+	src, err := d.addAccept(s)
+	if err != nil {
+		return err
+	}
+	d.sockets = append(d.sockets, s)
+	d.accepts = append(d.accepts, src)
+	return nil
+}
+
+// Sockets returns the sockets the display listens on.
+func (d *Display) Sockets() []*Socket {
+	return d.sockets
+}
+
+// Clients returns the connected clients.
+func (d *Display) Clients() []*Client {
+	return append([]*Client(nil), d.clients...)
+}
+
+// AddClientCreatedListener registers f to be called for every new client,
+// before any of its requests are handled.
+func (d *Display) AddClientCreatedListener(f func(*Client)) {
+	d.clientListeners = append(d.clientListeners, f)
+}
+
+// NextSerial returns a new serial number for an event.
+func (d *Display) NextSerial() uint32 {
+	d.serial++
+	return d.serial
+}
+
+// CreateClient adds a client connected through conn, as when it is accepted
+// from a display socket. It can be used to serve a client over a socketpair.
+func (d *Display) CreateClient(conn *net.UnixConn) (*Client, error) {
+	creds, err := PeerCredentials(conn)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{display: d, conn: gen.NewConn(conn), creds: creds}
+	c.SetLimits(d.limits)
+	fd, err := socketFd(conn)
+	if err != nil {
+		return nil, err
+	}
+	c.source, err = d.loop.AddFd(fd, EventReadable, c.ready)
+	if err != nil {
+		return nil, err
+	}
+	c.displayRes, _ = c.NewResource(1, gen.WlDisplayInterface, 1)
+	c.displayRes.SetImplementation(displayImpl{c})
+	d.clients = append(d.clients, c)
+
+	for _, f := range d.clientListeners {
+		f(c)
+	}
+	return c, nil
+}
+
+func (d *Display) removeClient(c *Client) {
+	for i, v := range d.clients {
+		if v == c {
+			d.clients = append(d.clients[:i], d.clients[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close disconnects every client and closes every socket, removing those
+// created by the display, and then the event loop. Like everything else, it
+// must be called from the goroutine running Run, or once Run has returned.
+func (d *Display) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	close(d.done)
+	for _, c := range d.Clients() {
+		c.Destroy()
+	}
+	for _, src := range d.accepts {
+		src.Remove()
+	}
+	var err error
+	for _, s := range d.sockets {
+		if cerr := s.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	d.sockets, d.accepts = nil, nil
+	if cerr := d.loop.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Event masks for file descriptor sources.
+const (
+	EventReadable = unix.EPOLLIN
+	EventWritable = unix.EPOLLOUT
+	EventHangup   = unix.EPOLLHUP
+	EventError    = unix.EPOLLERR
+)
+
+var errLoopClosed = errors.New("event loop closed")
+
+// An EventLoop multiplexes file descriptors, timers, signals and idle work
+// on one goroutine, like libwayland's wl_event_loop. Callbacks run on the
+// goroutine calling Dispatch; sources may be added and removed from within
+// them.
+//
+// An EventLoop is not goroutine-safe, except for Post, which hands work to
+// the loop from other goroutines.
+type EventLoop struct {
+	epfd   int
+	wakefd int
+	// sources holds the file descriptor sources by id. The id, not the
+	// fd, goes in each epoll event, so that a pending event of a removed
+	// source never reaches a new source given the same fd.
+	sources map[int32]*EventSource
+	nextID  int32
+	idle    []*EventSource
+	signals []*EventSource
+	events  [32]unix.EpollEvent
+	closed  bool
+
+	mu      sync.Mutex
+	posted  []func()
+	waking  bool
+	dropped bool
+}
+
+// An EventSource is something registered with an EventLoop: a file
+// descriptor, a timer, a signal or an idle callback.
+type EventSource struct {
+	loop    *EventLoop
+	fd      int
+	id      int32
+	removed bool
+
+	fdFunc func(fd int, mask uint32)
+	ownFd  bool
+	timer  func()
+	sig    chan os.Signal
+	idle   func()
+}
+
+// NewEventLoop creates an event loop.
+func NewEventLoop() (*EventLoop, error) {
+	epfd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("epoll_create1", err)
+	}
+	wakefd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		unix.Close(epfd)
+		return nil, os.NewSyscallError("eventfd", err)
+	}
+	l := &EventLoop{epfd: epfd, wakefd: wakefd, sources: make(map[int32]*EventSource)}
+	if _, err := l.AddFd(wakefd, EventReadable, l.runPosted); err != nil {
+		unix.Close(wakefd)
+		unix.Close(epfd)
+		return nil, err
+	}
+	return l, nil
+}
+
+// Fd returns the loop's epoll file descriptor. It becomes readable whenever
+// the loop has something to dispatch, so the whole loop can be embedded in
+// another one by polling Fd and calling DispatchPending.
+func (l *EventLoop) Fd() int {
+	return l.epfd
+}
+
+// AddFd calls f with the fd and the events that occurred whenever fd is
+// ready for one of the events in mask. The caller keeps ownership of fd,
+// and must remove the source before closing it.
+func (l *EventLoop) AddFd(fd int, mask uint32, f func(fd int, mask uint32)) (*EventSource, error) {
+	return l.addFd(&EventSource{loop: l, fd: fd, fdFunc: f}, mask)
+}
+
+func (l *EventLoop) addFd(s *EventSource, mask uint32) (*EventSource, error) {
+	if l.closed {
+		return nil, errLoopClosed
+	}
+	l.nextID++
+	s.id = l.nextID
+	ev := unix.EpollEvent{Events: mask, Fd: s.id}
+	if err := unix.EpollCtl(l.epfd, unix.EPOLL_CTL_ADD, s.fd, &ev); err != nil {
+		return nil, os.NewSyscallError("epoll_ctl", err)
+	}
+	l.sources[s.id] = s
+	return s, nil
+}
+
+// AddTimer creates a timer that calls f on expiry. It starts disarmed; see
+// SetTimer.
+func (l *EventLoop) AddTimer(f func()) (*EventSource, error) {
+	fd, err := unix.TimerfdCreate(unix.CLOCK_MONOTONIC, unix.TFD_CLOEXEC|unix.TFD_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("timerfd_create", err)
+	}
+	s := &EventSource{loop: l, fd: fd, ownFd: true, timer: f}
+	s.fdFunc = s.expire
+	if _, err := l.addFd(s, EventReadable); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	return s, nil
+}
+
+// AddSignal calls f on the loop each time one of sigs is received. The
+// signals are caught with os/signal, so they no longer have their default
+// effect while the source exists.
+func (l *EventLoop) AddSignal(f func(os.Signal), sigs ...os.Signal) (*EventSource, error) {
+	if l.closed {
+		return nil, errLoopClosed
+	}
+	s := &EventSource{loop: l, fd: -1, sig: make(chan os.Signal, 1)}
+	signal.Notify(s.sig, sigs...)
+	l.signals = append(l.signals, s)
+	go func() {
+		for sig := range s.sig {
+			sig := sig
+			l.Post(func() {
+				if !s.removed {
+					f(sig)
+				}
+			})
+		}
+	}()
+	return s, nil
+}
+
+// AddIdle calls f once the loop has dispatched the events at hand, before
+// it next waits. The source is removed once f has run.
+func (l *EventLoop) AddIdle(f func()) *EventSource {
+	s := &EventSource{loop: l, fd: -1, idle: f}
+	l.idle = append(l.idle, s)
+	return s
+}
+
+// Post schedules f to run on the loop's goroutine during a later dispatch.
+// Unlike the rest of the API, it may be called from any goroutine. Work
+// posted after the loop is closed is dropped.
+func (l *EventLoop) Post(f func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.dropped {
+		return
+	}
+	l.posted = append(l.posted, f)
+	if !l.waking {
+		l.waking = true
+		var one = [8]byte{1}
+		unix.Write(l.wakefd, one[:])
+	}
+}
+
+func (l *EventLoop) runPosted(fd int, mask uint32) {
+	var buf [8]byte
+	unix.Read(l.wakefd, buf[:])
+	l.mu.Lock()
+	posted := l.posted
+	l.posted = nil
+	l.waking = false
+	l.mu.Unlock()
+	for _, f := range posted {
+		f()
+	}
+}
+
+// Update changes the events a file descriptor source waits for.
+func (s *EventSource) Update(mask uint32) error {
+	if s.removed || s.fdFunc == nil || s.ownFd {
+		return errors.New("Update: not a file descriptor source")
+	}
+	ev := unix.EpollEvent{Events: mask, Fd: s.id}
+	return os.NewSyscallError("epoll_ctl", unix.EpollCtl(s.loop.epfd, unix.EPOLL_CTL_MOD, s.fd, &ev))
+}
+
+// SetTimer arms a timer source to fire once after d, or disarms it if d is
+// zero.
+func (s *EventSource) SetTimer(d time.Duration) error {
+	if s.removed || s.timer == nil {
+		return errors.New("SetTimer: not a timer source")
+	}
+	if d < 0 {
+		d = time.Nanosecond
+	}
+	spec := unix.ItimerSpec{Value: unix.NsecToTimespec(int64(d))}
+	return os.NewSyscallError("timerfd_settime", unix.TimerfdSettime(s.fd, 0, &spec, nil))
+}
+
+func (s *EventSource) expire(fd int, mask uint32) {
+	var buf [8]byte
+	if n, _ := unix.Read(fd, buf[:]); n == len(buf) {
+		s.timer()
+	}
+}
+
+// Remove unregisters the source. Its callback is not called afterwards,
+// even for events already received.
+func (s *EventSource) Remove() {
+	if s.removed {
+		return
+	}
+	s.removed = true
+	l := s.loop
+	switch {
+	case s.sig != nil:
+		signal.Stop(s.sig)
+		close(s.sig)
+		for i, v := range l.signals {
+			if v == s {
+				l.signals = append(l.signals[:i], l.signals[i+1:]...)
+				break
+			}
+		}
+	case s.idle != nil:
+		for i, v := range l.idle {
+			if v == s {
+				l.idle = append(l.idle[:i], l.idle[i+1:]...)
+				break
+			}
+		}
+	default:
+		delete(l.sources, s.id)
+		if !l.closed {
+			unix.EpollCtl(l.epfd, unix.EPOLL_CTL_DEL, s.fd, nil)
+		}
+		if s.ownFd {
+			unix.Close(s.fd)
+		}
+	}
+}
+
+// DispatchIdle runs the pending idle callbacks, including any they add.
+func (l *EventLoop) DispatchIdle() {
+	for len(l.idle) > 0 {
+		s := l.idle[0]
+		l.idle = l.idle[1:]
+		s.removed = true
+		s.idle()
+	}
+}
+
+// Dispatch runs idle callbacks, waits until at least one source is ready or
+// ctx is done, and dispatches the ready sources.
+func (l *EventLoop) Dispatch(ctx context.Context) error {
+	l.DispatchIdle()
+	if ctx.Done() != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				l.Post(func() {})
+			case <-stop:
+			}
+		}()
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		timeout := -1
+		if d, ok := ctx.Deadline(); ok {
+			left := time.Until(d)
+			if left <= 0 {
+				return context.DeadlineExceeded
+			}
+			timeout = int((left + time.Millisecond - 1) / time.Millisecond)
+		}
+		woken, err := l.wait(timeout)
+		if err != nil {
+			return err
+		}
+		if woken {
+			return ctx.Err()
+		}
+	}
+}
+
+// DispatchPending dispatches the sources that are ready without waiting.
+func (l *EventLoop) DispatchPending() error {
+	l.DispatchIdle()
+	_, err := l.wait(0)
+	return err
+}
+
+// wait waits up to timeout milliseconds for sources to become ready and
+// dispatches them. It reports whether any were, which they are not if the
+// wait timed out or was interrupted by a signal.
+func (l *EventLoop) wait(timeout int) (bool, error) {
+	if l.closed {
+		return false, errLoopClosed
+	}
+	n, err := unix.EpollWait(l.epfd, l.events[:], timeout)
+	if err == unix.EINTR {
+		return false, nil
+	}
+	if err != nil {
+		return false, os.NewSyscallError("epoll_wait", err)
+	}
+	for i := 0; i < n && !l.closed; i++ {
+		ev := l.events[i]
+		if s := l.sources[ev.Fd]; s != nil && !s.removed {
+			s.fdFunc(s.fd, ev.Events)
+		}
+	}
+	return n > 0, nil
+}
+
+// Close removes every source and releases the loop.
+func (l *EventLoop) Close() error {
+	if l.closed {
+		return nil
+	}
+	l.mu.Lock()
+	l.dropped = true
+	l.posted = nil
+	l.mu.Unlock()
+	for _, s := range l.sources {
+		s.Remove()
+	}
+	for len(l.idle) > 0 {
+		l.idle[0].Remove()
+	}
+	for len(l.signals) > 0 {
+		l.signals[0].Remove()
+	}
+	l.closed = true
+	unix.Close(l.wakefd)
+	return os.NewSyscallError("close", unix.Close(l.epfd))
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// A BindFunc is called when a client binds a global. The resource has
+// already been created with the version the client asked for; the function
+// typically sets its implementation and sends its initial events.
+type BindFunc func(r *Resource)
+
+// A GlobalFilter decides whether a global is visible to a client. Globals a
+// client cannot see are not announced to it and cannot be bound by it.
+type GlobalFilter func(c *Client, g *Global) bool
+
+// A Global is an object advertised to clients through wl_registry.
+type Global struct {
+	display *Display
+	name    uint32
+	iface   *gen.Interface
+	version uint32
+	bind    BindFunc
+
+	removed  bool
+	userData interface{}
+}
+
+// AddGlobal advertises a global implementing iface at up to version to every
+// client that can see it. bind is called for each client that binds it.
+func (d *Display) AddGlobal(iface *gen.Interface, version uint32, bind BindFunc) (*Global, error) {
+	if version == 0 || version > iface.Version {
+		return nil, fmt.Errorf("AddGlobal: %s version %d not in 1-%d", iface.Name, version, iface.Version)
+	}
+	d.nextGlobal++
+	g := &Global{display: d, name: d.nextGlobal, iface: iface, version: version, bind: bind}
+	d.globals = append(d.globals, g)
+	for _, c := range d.clients {
+		if c.canSee(g) {
+			for _, r := range c.registries {
+				g.announce(r)
+			}
+		}
+	}
+	return g, nil
+}
+
+// Globals returns the globals of the display, including those removed but
+// not yet destroyed.
+func (d *Display) Globals() []*Global {
+	return append([]*Global(nil), d.globals...)
+}
+
+// SetGlobalFilter sets the filter deciding which globals each client can
+// see, or removes it if f is nil. It should be set before clients connect:
+// changing it does not announce or withdraw globals from existing registries.
+func (d *Display) SetGlobalFilter(f GlobalFilter) {
+	d.globalFilter = f
+}
+
+func (g *Global) announce(r *Resource) {
+	r.PostEvent(0, gen.WlUint(g.name), gen.WlString(g.iface.Name), gen.WlUint(g.version))
+}
+
+// Name returns the numeric name the global is advertised under.
+func (g *Global) Name() uint32 {
+	return g.name
+}
+
+// Interface returns the interface the global implements.
+func (g *Global) Interface() *gen.Interface {
+	return g.iface
+}
+
+// Version returns the highest version of the global clients may bind.
+func (g *Global) Version() uint32 {
+	return g.version
+}
+
+// Display returns the display the global belongs to.
+func (g *Global) Display() *Display {
+	return g.display
+}
+
+// UserData returns the value set with SetUserData.
+func (g *Global) UserData() interface{} {
+	return g.userData
+}
+
+// SetUserData attaches an arbitrary value to the global.
+func (g *Global) SetUserData(v interface{}) {
+	g.userData = v
+}
+
+// Remove withdraws the global, sending wl_registry.global_remove to the
+// clients that could see it. Clients may still bind it until it is
+// destroyed, since a bind may already be on its way; such binds get a
+// resource but do not reach the bind function.
+func (g *Global) Remove() {
+	if g.removed {
+		return
+	}
+	g.removed = true
+	for _, c := range g.display.clients {
+		if c.canSee(g) {
+			for _, r := range c.registries {
+				r.PostEvent(1, gen.WlUint(g.name))
+			}
+		}
+	}
+}
+
+// Destroy removes the global if it has not been removed and forgets it, so
+// that binding its name becomes a protocol error. Compositors should leave
+// some time between Remove and Destroy for clients to see the removal.
+func (g *Global) Destroy() {
+	g.Remove()
+	d := g.display
+	for i, v := range d.globals {
+		if v == g {
+			d.globals = append(d.globals[:i], d.globals[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *Client) canSee(g *Global) bool {
+	f := c.display.globalFilter
+	return f == nil || f(c, g)
+}
+
+func (c *Client) lookupGlobal(name uint32) *Global {
+	for _, g := range c.display.globals {
+		if g.name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// registryImpl implements a client's wl_registry objects.
+type registryImpl struct {
+	client *Client
+}
+
+func (c *Client) newRegistry(id uint32) {
+	r, err := c.NewResource(id, gen.WlRegistryInterface, 1)
+	if err != nil {
+		return
+	}
+	r.SetImplementation(registryImpl{c})
+	c.registries = append(c.registries, r)
+	r.AddDestroyListener(func(r *Resource) {
+		for i, v := range c.registries {
+			if v == r {
+				c.registries = append(c.registries[:i], c.registries[i+1:]...)
+				return
+			}
+		}
+	})
+	for _, g := range c.display.globals {
+		if !g.removed && c.canSee(g) {
+			g.announce(r)
+		}
+	}
+}
+
+func (ri registryImpl) Bind(Name gen.WlUint, Interface gen.WlString, Version gen.WlUint, Id gen.WlNewId) {
+	c := ri.client
+	g := c.lookupGlobal(uint32(Name))
+	switch {
+	case g == nil || !c.canSee(g):
+		c.PostError(1, uint32(gen.WlDisplayInvalidObject), "invalid global %s (%d)", Interface, Name)
+		return
+	case string(Interface) != g.iface.Name:
+		c.PostError(1, uint32(gen.WlDisplayInvalidObject), "invalid interface for global %d: have %s, wanted %s",
+			Name, Interface, g.iface.Name)
+		return
+	case Version == 0 || uint32(Version) > g.version:
+		c.PostError(1, uint32(gen.WlDisplayInvalidObject), "invalid version for global %s (%d): have %d, wanted %d",
+			Interface, Name, g.version, Version)
+		return
+	}
+	r, err := c.NewResource(uint32(Id), g.iface, uint32(Version))
+	if err != nil {
+		return
+	}
+	if !g.removed && g.bind != nil {
+		g.bind(r)
+	}
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// Limits bound what one client may cost the server, so that a client that
+// creates objects without end, floods it with requests, stops reading its
+// socket or sends file descriptors it never uses cannot bring it down. A
+// client that exceeds a limit is disconnected. Zero fields are unlimited.
+//
+// Messages are at most 4096 bytes, strings and arrays included, whatever the
+// limits.
+type Limits struct {
+	// MaxObjects is the number of live resources a client may have,
+	// whether it or the server allocated their IDs.
+	MaxObjects int
+	// MaxRequestRate is the sustained number of requests a client may send
+	// per second, and RequestBurst the number it may send at once above
+	// that rate. A zero RequestBurst allows one second's worth.
+	MaxRequestRate float64
+	RequestBurst   int
+	// MaxOutgoingBytes is the size of the events buffered for a client
+	// because its socket is full.
+	MaxOutgoingBytes int
+	// MaxPendingFDs is the number of file descriptors received from a
+	// client and not yet taken by a request.
+	MaxPendingFDs int
+}
+
+// DefaultLimits are the limits of a new Display. They stop runaway clients
+// without getting in the way of busy ones; requests are not rate limited.
+var DefaultLimits = Limits{
+	MaxObjects:       1 << 16,
+	MaxOutgoingBytes: 4 << 20,
+	MaxPendingFDs:    1024,
+}
+
+// A LimitKind names one of the Limits.
+type LimitKind int
+
+const (
+	LimitObjects LimitKind = iota + 1
+	LimitRequestRate
+	LimitOutgoingBytes
+	LimitPendingFDs
+)
+
+func (k LimitKind) String() string {
+	switch k {
+	case LimitObjects:
+		return "objects"
+	case LimitRequestRate:
+		return "request rate"
+	case LimitOutgoingBytes:
+		return "outgoing bytes"
+	case LimitPendingFDs:
+		return "pending fds"
+	}
+	return fmt.Sprintf("LimitKind(%d)", int(k))
+}
+
+// A LimitError is the reason a client was disconnected for exceeding one of
+// its Limits.
+type LimitError struct {
+	Kind LimitKind
+	// Value is what the client reached and Max the limit. For the request
+	// rate, Value is the number of requests in the current second.
+	Value, Max float64
+	// Credentials identify the client process.
+	Credentials Credentials
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("client pid %d uid %d: %s limit exceeded (%g, limit %g)",
+		e.Credentials.Pid, e.Credentials.Uid, e.Kind, e.Value, e.Max)
+}
+
+// A LimitFunc is told about each client disconnected for exceeding its
+// limits, just before it is disconnected.
+type LimitFunc func(c *Client, err *LimitError)
+
+func logLimit(c *Client, err *LimitError) {
+	log.Printf("wayland: disconnecting %v", err)
+}
+
+// SetLimits sets the limits of the clients that connect from now on.
+// Clients already connected keep theirs; see Client.SetLimits.
+func (d *Display) SetLimits(l Limits) {
+	d.limits = l
+}
+
+// Limits returns the limits new clients get.
+func (d *Display) Limits() Limits {
+	return d.limits
+}
+
+// SetLimitHandler sets the function told about clients disconnected for
+// exceeding their limits. The default logs the reason with the log
+// package; nil restores it.
+func (d *Display) SetLimitHandler(f LimitFunc) {
+	if f == nil {
+		f = logLimit
+	}
+	d.limitFunc = f
+}
+
+// SetLimits changes the client's limits, which start as the display's.
+// Lowered limits are checked from the next time they could be exceeded.
+func (c *Client) SetLimits(l Limits) {
+	c.limits = l
+	c.tokens = float64(l.burst())
+}
+
+// Limits returns the client's limits.
+func (c *Client) Limits() Limits {
+	return c.limits
+}
+
+func (l Limits) burst() int {
+	if l.RequestBurst > 0 {
+		return l.RequestBurst
+	}
+	return int(l.MaxRequestRate)
+}
+
+// exceed disconnects the client for exceeding a limit. The client is told
+// with wl_display.error if its socket can take it, and is destroyed once
+// the loop goes idle; nothing more is read from or sent to it meanwhile.
+func (c *Client) exceed(kind LimitKind, value, max float64) {
+	if c.disconnecting || c.destroyed {
+		return
+	}
+	err := &LimitError{Kind: kind, Value: value, Max: max, Credentials: c.creds}
+	c.display.limitFunc(c, err)
+	if kind != LimitOutgoingBytes && !c.errored {
+		c.displayRes.PostEvent(0, gen.WlObject(1), gen.WlUint(gen.WlDisplayNoMemory), gen.WlString(err.Error()))
+	}
+	c.errored = true
+	c.disconnecting = true
+	c.display.loop.AddIdle(c.Destroy)
+}
+
+// checkObjects is called before a resource is added.
+func (c *Client) checkObjects() bool {
+	if max := c.limits.MaxObjects; max > 0 && c.objects.count >= max {
+		c.exceed(LimitObjects, float64(c.objects.count+1), float64(max))
+		return false
+	}
+	return true
+}
+
+// takeRequest accounts for a request against the rate limit, with a token
+// bucket refilled at the allowed rate.
+func (c *Client) takeRequest(now time.Time) bool {
+	rate := c.limits.MaxRequestRate
+	if rate <= 0 {
+		return true
+	}
+	burst := float64(c.limits.burst())
+	if !c.lastRequest.IsZero() {
+		c.tokens += now.Sub(c.lastRequest).Seconds() * rate
+		if c.tokens > burst {
+			c.tokens = burst
+		}
+	}
+	c.lastRequest = now
+	if now.Sub(c.second) >= time.Second {
+		c.second, c.secondCount = now, 0
+	}
+	c.secondCount++
+	if c.tokens < 1 {
+		c.exceed(LimitRequestRate, float64(c.secondCount), rate)
+		return false
+	}
+	c.tokens--
+	return true
+}
+
+// checkPendingFDs is called after each read from the socket.
+func (c *Client) checkPendingFDs() bool {
+	if max := c.limits.MaxPendingFDs; max > 0 && c.conn.PendingFDs() > max {
+		c.exceed(LimitPendingFDs, float64(c.conn.PendingFDs()), float64(max))
+		return false
+	}
+	return true
+}
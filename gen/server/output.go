@@ -0,0 +1,225 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// outputDestroyDelay is how long a removed output's global stays bindable,
+// so that binds racing with the removal do not fail.
+const outputDestroyDelay = 5 * time.Second
+
+// An OutputMode is a video mode of an output.
+type OutputMode struct {
+	Width, Height int
+	// Refresh is the refresh rate in mHz, or zero if it does not apply.
+	Refresh   int
+	Preferred bool
+}
+
+// OutputInfo describes an output as wl_output announces it.
+type OutputInfo struct {
+	// X and Y are the output's position in the compositor's global space.
+	X, Y int
+	// PhysicalWidth and PhysicalHeight are the size in millimetres, or
+	// zero if unknown.
+	PhysicalWidth, PhysicalHeight int
+	Subpixel                      gen.WlOutputSubpixel
+	Make, Model                   string
+	Modes                         []OutputMode
+	// Current is the index of the current mode in Modes.
+	Current   int
+	Scale     int
+	Transform gen.WlOutputTransform
+}
+
+// validate checks info and fills in defaults.
+func (info *OutputInfo) validate() error {
+	switch {
+	case len(info.Modes) == 0:
+		return errors.New("no modes")
+	case info.Current < 0 || info.Current >= len(info.Modes):
+		return fmt.Errorf("current mode %d not in 0-%d", info.Current, len(info.Modes)-1)
+	case info.Scale < 0:
+		return fmt.Errorf("invalid scale %d", info.Scale)
+	case info.Transform > gen.WlOutputFlipped270:
+		return fmt.Errorf("invalid transform %d", info.Transform)
+	}
+	for _, m := range info.Modes {
+		if m.Width <= 0 || m.Height <= 0 {
+			return fmt.Errorf("invalid mode %dx%d", m.Width, m.Height)
+		}
+	}
+	if info.Scale == 0 {
+		info.Scale = 1
+	}
+	info.Modes = append([]OutputMode(nil), info.Modes...)
+	return nil
+}
+
+// An Output is a wl_output global describing a monitor, real or virtual.
+// Outputs can be added and removed while clients are connected, which they
+// see as registry events. The surfaces shown on an output are told with
+// wl_surface.enter and leave, through the Surface's EnterOutput and
+// LeaveOutput.
+type Output struct {
+	display   *Display
+	global    *Global
+	info      OutputInfo
+	resources []*Resource
+	surfaces  []*Surface
+	removed   bool
+}
+
+// AddOutput advertises an output described by info.
+func (d *Display) AddOutput(info OutputInfo) (*Output, error) {
+	if err := info.validate(); err != nil {
+		return nil, fmt.Errorf("AddOutput: %v", err)
+	}
+	o := &Output{display: d, info: info}
+	g, err := d.AddGlobal(gen.WlOutputInterface, 2, o.bind)
+	if err != nil {
+		return nil, err
+	}
+	g.SetUserData(o)
+	o.global = g
+	return o, nil
+}
+
+// OutputFromResource returns the Output behind a wl_output resource.
+func OutputFromResource(r *Resource) *Output {
+	if r == nil {
+		return nil
+	}
+	o, _ := r.UserData().(*Output)
+	return o
+}
+
+// Global returns the wl_output global.
+func (o *Output) Global() *Global {
+	return o.global
+}
+
+// Info returns the output's description.
+func (o *Output) Info() OutputInfo {
+	info := o.info
+	info.Modes = append([]OutputMode(nil), info.Modes...)
+	return info
+}
+
+// Update changes the output's description and sends it again to the clients
+// that bound the output.
+func (o *Output) Update(info OutputInfo) error {
+	if err := info.validate(); err != nil {
+		return fmt.Errorf("Update: %v", err)
+	}
+	o.info = info
+	for _, r := range o.resources {
+		o.sendInfo(r)
+	}
+	return nil
+}
+
+// Bounds returns the area the output covers in the global space: its
+// current mode, rotated by its transform and divided by its scale.
+func (o *Output) Bounds() image.Rectangle {
+	m := o.info.Modes[o.info.Current]
+	w, h := m.Width, m.Height
+	if o.info.Transform%2 == 1 {
+		w, h = h, w
+	}
+	w, h = w/o.info.Scale, h/o.info.Scale
+	return image.Rect(o.info.X, o.info.Y, o.info.X+w, o.info.Y+h)
+}
+
+// Resources returns the wl_output objects a client has bound for the
+// output.
+func (o *Output) Resources(c *Client) []*Resource {
+	var list []*Resource
+	for _, r := range o.resources {
+		if r.Client() == c {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// Surfaces returns the surfaces that have entered the output.
+func (o *Output) Surfaces() []*Surface {
+	return append([]*Surface(nil), o.surfaces...)
+}
+
+// Removed reports whether Remove has been called.
+func (o *Output) Removed() bool {
+	return o.removed
+}
+
+// Remove unplugs the output: the surfaces on it leave it and clients get
+// wl_registry.global_remove. The global itself is destroyed a few seconds
+// later, once clients have had time to see the removal.
+func (o *Output) Remove() {
+	if o.removed {
+		return
+	}
+	o.removed = true
+	for _, s := range o.Surfaces() {
+		s.LeaveOutput(o)
+	}
+	o.global.Remove()
+	var t *EventSource
+	t, err := o.display.EventLoop().AddTimer(func() {
+		o.global.Destroy()
+		t.Remove()
+	})
+	if err != nil {
+		o.global.Destroy()
+		return
+	}
+	t.SetTimer(outputDestroyDelay)
+}
+
+func (o *Output) bind(r *Resource) {
+	r.SetUserData(o)
+	o.resources = append(o.resources, r)
+	r.AddDestroyListener(func(r *Resource) {
+		for i, v := range o.resources {
+			if v == r {
+				o.resources = append(o.resources[:i], o.resources[i+1:]...)
+				return
+			}
+		}
+	})
+	o.sendInfo(r)
+	for _, s := range o.surfaces {
+		if s.res.Client() == r.Client() {
+			s.res.PostEvent(0, gen.WlObject(r.Id()))
+		}
+	}
+}
+
+// sendInfo sends the output's geometry, modes and scale, followed by done.
+func (o *Output) sendInfo(r *Resource) {
+	info := &o.info
+	r.PostEvent(0, gen.WlInt(info.X), gen.WlInt(info.Y),
+		gen.WlInt(info.PhysicalWidth), gen.WlInt(info.PhysicalHeight),
+		gen.WlInt(info.Subpixel), gen.WlString(info.Make), gen.WlString(info.Model),
+		gen.WlInt(info.Transform))
+	for i, m := range info.Modes {
+		var flags gen.WlOutputMode
+		if i == info.Current {
+			flags |= gen.WlOutputCurrent
+		}
+		if m.Preferred {
+			flags |= gen.WlOutputPreferred
+		}
+		r.PostEvent(1, gen.WlUint(flags), gen.WlInt(m.Width), gen.WlInt(m.Height), gen.WlInt(m.Refresh))
+	}
+	if r.Version() >= 2 {
+		r.PostEvent(3, gen.WlInt(info.Scale))
+		r.PostEvent(2)
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"image"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/region"
+)
+
+// NewRegion implements wl_region on r, as a compositor does from
+// wl_compositor.create_region. The returned region is updated by the
+// client's add and subtract requests.
+func NewRegion(r *Resource) *region.Region {
+	reg := &region.Region{}
+	r.SetImplementation(regionImpl{reg})
+	r.SetUserData(reg)
+	return reg
+}
+
+// RegionFromResource returns the region behind a wl_region resource.
+func RegionFromResource(r *Resource) *region.Region {
+	if r == nil {
+		return nil
+	}
+	reg, _ := r.UserData().(*region.Region)
+	return reg
+}
+
+// regionImpl implements wl_region.
+type regionImpl struct {
+	r *region.Region
+}
+
+func (ri regionImpl) Add(X gen.WlInt, Y gen.WlInt, Width gen.WlInt, Height gen.WlInt) {
+	if rect, ok := rectArgs(X, Y, Width, Height); ok {
+		*ri.r = ri.r.UnionRect(rect)
+	}
+}
+
+func (ri regionImpl) Subtract(X gen.WlInt, Y gen.WlInt, Width gen.WlInt, Height gen.WlInt) {
+	if rect, ok := rectArgs(X, Y, Width, Height); ok {
+		*ri.r = ri.r.SubtractRect(rect)
+	}
+}
+
+// rectArgs returns the rectangle of a request's x, y, width and height
+// arguments. As in pixman, rectangles with no area are ignored rather than
+// flipped into positive ones. The far edges are computed in int, so that
+// they do not wrap around near the limits of int32.
+func rectArgs(x, y, width, height gen.WlInt) (image.Rectangle, bool) {
+	if width <= 0 || height <= 0 {
+		return image.Rectangle{}, false
+	}
+	return image.Rect(int(x), int(y), int(x)+int(width), int(y)+int(height)), true
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Pursuit92/goland/gen"
+)
+
+// Object IDs from 1 up to serverIdStart-1 are allocated by the client, and
+// IDs from serverIdStart up by the server.
+const serverIdStart = 0xff000000
+
+// A Resource is the server side of a protocol object owned by a client.
+type Resource struct {
+	id      uint32
+	iface   *gen.Interface
+	version uint32
+	client  *Client
+
+	impl    interface{}
+	methods []reflect.Value
+
+	destroyed        bool
+	destroyListeners []func(*Resource)
+	userData         interface{}
+}
+
+// Id returns the object ID of the resource.
+func (r *Resource) Id() uint32 {
+	return r.id
+}
+
+// Interface returns the description of the interface the object implements.
+func (r *Resource) Interface() *gen.Interface {
+	return r.iface
+}
+
+// Version returns the interface version the object was created with.
+func (r *Resource) Version() uint32 {
+	return r.version
+}
+
+// Client returns the client that owns the resource.
+func (r *Resource) Client() *Client {
+	return r.client
+}
+
+// Destroyed reports whether the resource has been destroyed, for holders
+// of a resource that may go away under them, such as an attached buffer.
+func (r *Resource) Destroyed() bool {
+	return r.destroyed
+}
+
+// UserData returns the value set with SetUserData.
+func (r *Resource) UserData() interface{} {
+	return r.userData
+}
+
+// SetUserData attaches an arbitrary value to the resource.
+func (r *Resource) SetUserData(v interface{}) {
+	r.userData = v
+}
+
+// SetImplementation sets the value whose methods handle the resource's
+// requests. The methods are named and typed as in the generated interface,
+// so any implementation of, say, gen.WlSurface can be used for a wl_surface
+// resource, but impl only needs the methods for the requests it supports;
+// other requests are ignored. Object arguments have been checked to name
+// live resources of the right interface, and new_id arguments to be free
+// IDs, which the method creates with Client.NewResource.
+//
+// A destructor request destroys the resource after its method returns,
+// unless the method already did.
+func (r *Resource) SetImplementation(impl interface{}) error {
+	methods, err := gen.BindMethods(impl, r.iface.Requests)
+	if err != nil {
+		return fmt.Errorf("SetImplementation: %s: %v", r.iface.Name, err)
+	}
+	r.impl, r.methods = impl, methods
+	return nil
+}
+
+// Implementation returns the value set with SetImplementation.
+func (r *Resource) Implementation() interface{} {
+	return r.impl
+}
+
+// AddDestroyListener registers f to be called when the resource is
+// destroyed, either explicitly or because its client went away.
+func (r *Resource) AddDestroyListener(f func(*Resource)) {
+	r.destroyListeners = append(r.destroyListeners, f)
+}
+
+// PostEvent sends the event with the given opcode. The arguments have the
+// types used by the generated interface.
+func (r *Resource) PostEvent(opcode uint16, args ...interface{}) error {
+	if r.destroyed {
+		return fmt.Errorf("PostEvent: %s@%d has been destroyed", r.iface.Name, r.id)
+	}
+	if int(opcode) >= len(r.iface.Events) {
+		return fmt.Errorf("PostEvent: invalid opcode %d for %s", opcode, r.iface.Name)
+	}
+	ev := &r.iface.Events[opcode]
+	if ev.Since > r.version {
+		return fmt.Errorf("PostEvent: %s.%s requires version %d, object has version %d", r.iface.Name, ev.Name, ev.Since, r.version)
+	}
+	msg, fds, err := gen.NewMessage(r.id, opcode, ev, args...)
+	if err != nil {
+		return err
+	}
+	return r.client.send(msg, fds)
+}
+
+// PostError sends a fatal protocol error about the resource to its client,
+// which is disconnected once the request being handled is done. code is
+// normally a value of the interface's error enum.
+func (r *Resource) PostError(code uint32, format string, args ...interface{}) {
+	r.client.PostError(r.id, code, format, args...)
+}
+
+// Destroy destroys the resource. If the client allocated its ID, the client
+// is told with wl_display.delete_id that the ID may be reused.
+func (r *Resource) Destroy() {
+	if r.destroyed {
+		return
+	}
+	c := r.client
+	c.objects.remove(r)
+	if r.id < serverIdStart && !c.destroyed {
+		c.displayRes.PostEvent(1, gen.WlUint(r.id))
+	}
+	r.destroy()
+}
+
+// destroy runs the destroy listeners once the resource has left the map.
+func (r *Resource) destroy() {
+	r.destroyed = true
+	for i := len(r.destroyListeners) - 1; i >= 0; i-- {
+		r.destroyListeners[i](r)
+	}
+	r.destroyListeners = nil
+}
+
+// resourceMap tracks a client's live resources. Slot i of client holds
+// object i+1 and slot i of server holds object serverIdStart+i. A nil slot
+// is unused.
+type resourceMap struct {
+	client []*Resource
+	server []*Resource
+	free   []uint32
+	// count is the number of live resources.
+	count int
+}
+
+// validNewId reports whether a client may create an object with id: it
+// must reuse a free slot or be the next unused ID.
+func (m *resourceMap) validNewId(id uint32) bool {
+	if id == 0 || id >= serverIdStart {
+		return false
+	}
+	i := int(id - 1)
+	return i == len(m.client) || (i < len(m.client) && m.client[i] == nil)
+}
+
+func (m *resourceMap) insertAt(r *Resource) error {
+	if !m.validNewId(r.id) {
+		return fmt.Errorf("invalid new object ID %d", r.id)
+	}
+	if i := int(r.id - 1); i == len(m.client) {
+		m.client = append(m.client, r)
+	} else {
+		m.client[i] = r
+	}
+	m.count++
+	return nil
+}
+
+// insertNew allocates a server side ID for r.
+func (m *resourceMap) insertNew(r *Resource) {
+	m.count++
+	if n := len(m.free); n > 0 {
+		r.id = m.free[n-1]
+		m.free = m.free[:n-1]
+		m.server[r.id-serverIdStart] = r
+		return
+	}
+	m.server = append(m.server, r)
+	r.id = serverIdStart + uint32(len(m.server)-1)
+}
+
+func (m *resourceMap) lookup(id uint32) *Resource {
+	if id >= serverIdStart {
+		if i := int(id - serverIdStart); i < len(m.server) {
+			return m.server[i]
+		}
+		return nil
+	}
+	if id == 0 || int(id) > len(m.client) {
+		return nil
+	}
+	return m.client[id-1]
+}
+
+func (m *resourceMap) remove(r *Resource) {
+	if r.id >= serverIdStart {
+		if i := int(r.id - serverIdStart); i < len(m.server) && m.server[i] == r {
+			m.server[i] = nil
+			m.free = append(m.free, r.id)
+			m.count--
+		}
+		return
+	}
+	if i := int(r.id - 1); i < len(m.client) && m.client[i] == r {
+		m.client[i] = nil
+		m.count--
+	}
+}
+
+// all returns every live resource, client allocated ones first.
+func (m *resourceMap) all() []*Resource {
+	var rs []*Resource
+	for _, list := range [][]*Resource{m.client, m.server} {
+		for _, r := range list {
+			if r != nil {
+				rs = append(rs, r)
+			}
+		}
+	}
+	return rs
+}
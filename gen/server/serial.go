@@ -0,0 +1,137 @@
+package server
+
+// serialHistory is how many input events a SerialTracker remembers.
+const serialHistory = 64
+
+// A SerialKind tells which input event a serial was sent with.
+type SerialKind int
+
+const (
+	SerialPointerEnter SerialKind = iota + 1
+	SerialPointerLeave
+	SerialPointerButton
+	SerialKeyboardEnter
+	SerialKeyboardLeave
+	SerialKey
+	SerialModifiers
+	SerialTouchDown
+	SerialTouchUp
+	SerialDragEnter
+)
+
+// A SerialRecord describes the input event a serial was sent with.
+type SerialRecord struct {
+	Serial uint32
+	Kind   SerialKind
+	// Surface is the surface the event was sent for, if any.
+	Surface *Surface
+	// Button is the pointer button or key of a button or key event, or
+	// the touch point of a touch event.
+	Button uint32
+	// Pressed is the state of a button or key event.
+	Pressed bool
+}
+
+// A SerialTracker hands out the serials of a seat's input events and
+// remembers the last few dozen, so that requests carrying a serial, such as
+// wl_shell_surface.move, wl_pointer.set_cursor and wl_data_device.start_drag,
+// can be checked against the event they claim to answer. Serials come from
+// the display, so they are unique among all the display's events.
+type SerialTracker struct {
+	display *Display
+	// records holds the recent events, oldest first.
+	records []SerialRecord
+}
+
+// NewSerialTracker returns a tracker for the input events of one seat.
+func NewSerialTracker(d *Display) *SerialTracker {
+	return &SerialTracker{display: d}
+}
+
+// Next takes a new serial for an event, records it and returns it.
+func (t *SerialTracker) Next(kind SerialKind, surface *Surface, button uint32, pressed bool) uint32 {
+	rec := SerialRecord{
+		Serial:  t.display.NextSerial(),
+		Kind:    kind,
+		Surface: surface,
+		Button:  button,
+		Pressed: pressed,
+	}
+	if len(t.records) == serialHistory {
+		copy(t.records, t.records[1:])
+		t.records = t.records[:serialHistory-1]
+	}
+	t.records = append(t.records, rec)
+	return rec.Serial
+}
+
+// Lookup returns the record of a recent serial. It fails for serials that
+// were never sent, for other seats' serials, and for serials too old to
+// be remembered.
+func (t *SerialTracker) Lookup(serial uint32) (SerialRecord, bool) {
+	i := t.index(serial)
+	if i < 0 {
+		return SerialRecord{}, false
+	}
+	return t.records[i], true
+}
+
+// Latest returns the record of the most recent event of a kind.
+func (t *SerialTracker) Latest(kind SerialKind) (SerialRecord, bool) {
+	for i := len(t.records) - 1; i >= 0; i-- {
+		if t.records[i].Kind == kind {
+			return t.records[i], true
+		}
+	}
+	return SerialRecord{}, false
+}
+
+// IsLatest reports whether serial is that of the most recent event of a
+// kind and was sent for a surface of client c. It is the check for
+// requests answering a focus change, such as wl_pointer.set_cursor, which
+// must answer the current pointer enter.
+func (t *SerialTracker) IsLatest(serial uint32, kind SerialKind, c *Client) bool {
+	rec, ok := t.Latest(kind)
+	return ok && rec.Serial == serial && rec.Surface != nil && rec.Surface.res.Client() == c
+}
+
+// ImplicitGrab returns the record of a pointer button press or touch down
+// with the given serial, if it was sent for a surface of client c and the
+// button or touch point is still down: no later release, touch up or
+// pointer leave has been recorded. It is the check for requests that start
+// a grab, such as wl_shell_surface.move and wl_data_device.start_drag.
+func (t *SerialTracker) ImplicitGrab(serial uint32, c *Client) (SerialRecord, bool) {
+	i := t.index(serial)
+	if i < 0 {
+		return SerialRecord{}, false
+	}
+	rec := t.records[i]
+	switch {
+	case rec.Surface == nil || rec.Surface.res.Client() != c:
+		return SerialRecord{}, false
+	case rec.Kind == SerialPointerButton && rec.Pressed:
+		for _, v := range t.records[i+1:] {
+			if v.Kind == SerialPointerLeave || v.Kind == SerialPointerButton && !v.Pressed && v.Button == rec.Button {
+				return SerialRecord{}, false
+			}
+		}
+	case rec.Kind == SerialTouchDown:
+		for _, v := range t.records[i+1:] {
+			if v.Kind == SerialTouchUp && v.Button == rec.Button {
+				return SerialRecord{}, false
+			}
+		}
+	default:
+		return SerialRecord{}, false
+	}
+	return rec, true
+}
+
+func (t *SerialTracker) index(serial uint32) int {
+	for i := len(t.records) - 1; i >= 0; i-- {
+		if t.records[i].Serial == serial {
+			return i
+		}
+	}
+	return -1
+}
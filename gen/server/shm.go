@@ -0,0 +1,413 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"runtime/debug"
+	"unsafe"
+
+	"github.com/Pursuit92/goland/gen"
+	"golang.org/x/sys/unix"
+)
+
+// Shm is the server's wl_shm global: it lets clients share memory pools
+// with the compositor and carve buffers out of them.
+type Shm struct {
+	global  *Global
+	formats []gen.WlShmFormat
+}
+
+// InitShm advertises wl_shm with the given formats in addition to
+// WlShmArgb8888 and WlShmXrgb8888, which every compositor must support.
+func (d *Display) InitShm(formats ...gen.WlShmFormat) (*Shm, error) {
+	s := &Shm{formats: []gen.WlShmFormat{gen.WlShmArgb8888, gen.WlShmXrgb8888}}
+	for _, f := range formats {
+		if !s.Supports(f) {
+			s.formats = append(s.formats, f)
+		}
+	}
+	g, err := d.AddGlobal(gen.WlShmInterface, 1, s.bind)
+	if err != nil {
+		return nil, err
+	}
+	s.global = g
+	return s, nil
+}
+
+// Global returns the wl_shm global.
+func (s *Shm) Global() *Global {
+	return s.global
+}
+
+// Formats returns the advertised formats.
+func (s *Shm) Formats() []gen.WlShmFormat {
+	return append([]gen.WlShmFormat(nil), s.formats...)
+}
+
+// Supports reports whether f is advertised.
+func (s *Shm) Supports(f gen.WlShmFormat) bool {
+	for _, v := range s.formats {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Shm) bind(r *Resource) {
+	r.SetImplementation(shmImpl{s, r})
+	for _, f := range s.formats {
+		r.PostEvent(0, gen.WlUint(f))
+	}
+}
+
+// shmImpl implements a client's wl_shm object.
+type shmImpl struct {
+	shm *Shm
+	res *Resource
+}
+
+func (s shmImpl) CreatePool(Id gen.WlNewId, Fd gen.WlFd, Size gen.WlInt) {
+	fd := int(Fd)
+	if Size <= 0 {
+		unix.Close(fd)
+		s.res.PostError(uint32(gen.WlShmInvalidStride), "invalid size (%d)", Size)
+		return
+	}
+	data, err := unix.Mmap(fd, 0, int(Size), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		unix.Close(fd)
+		s.res.PostError(uint32(gen.WlShmInvalidFd), "failed mmap fd %d: %v", fd, err)
+		return
+	}
+	r, err := s.res.Client().NewResource(uint32(Id), gen.WlShmPoolInterface, s.res.Version())
+	if err != nil {
+		unix.Munmap(data)
+		unix.Close(fd)
+		return
+	}
+	p := &ShmPool{shm: s.shm, shmRes: s.res, res: r, fd: fd, data: data, refs: 1}
+	p.checkSealed()
+	r.SetImplementation(shmPoolImpl{p})
+	r.AddDestroyListener(func(*Resource) { p.unref() })
+}
+
+// A ShmPool is a client's wl_shm_pool: a shared memory file mapped into the
+// server. It stays mapped while the pool object or any of its buffers
+// exists.
+type ShmPool struct {
+	shm    *Shm
+	shmRes *Resource
+	res    *Resource
+	fd     int
+	data   []byte
+	refs   int
+
+	// Mappings replaced by resize, kept until the pool is freed because
+	// buffer data handed out earlier may still point into them.
+	retired [][]byte
+
+	// sealed is set when the file cannot shrink below the mapping, so
+	// reading it cannot fault.
+	sealed bool
+}
+
+// checkSealed records whether the pool's file is sealed against shrinking
+// and large enough for the mapping, as memfds created by careful clients
+// are.
+func (p *ShmPool) checkSealed() {
+	p.sealed = false
+	seals, err := unix.FcntlInt(uintptr(p.fd), unix.F_GET_SEALS, 0)
+	if err != nil || seals&unix.F_SEAL_SHRINK == 0 {
+		return
+	}
+	var st unix.Stat_t
+	if unix.Fstat(p.fd, &st) != nil || st.Size < int64(len(p.data)) {
+		return
+	}
+	p.sealed = true
+}
+
+// mapped reports whether addr lies in one of the pool's mappings.
+func (p *ShmPool) mapped(addr uintptr) bool {
+	in := func(m []byte) bool {
+		if len(m) == 0 {
+			return false
+		}
+		start := uintptr(unsafe.Pointer(&m[0]))
+		return addr >= start && addr < start+uintptr(len(m))
+	}
+	if in(p.data) {
+		return true
+	}
+	for _, m := range p.retired {
+		if in(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size returns the size of the pool's mapping.
+func (p *ShmPool) Size() int {
+	return len(p.data)
+}
+
+// postError reports a wl_shm error about the pool. The error is posted on
+// the wl_shm object the pool came from, whose interface defines the codes,
+// so that clients can name it.
+func (p *ShmPool) postError(code gen.WlShmError, format string, args ...interface{}) {
+	p.shmRes.PostError(uint32(code), "wl_shm_pool@%d: "+format, append([]interface{}{p.res.Id()}, args...)...)
+}
+
+func (p *ShmPool) unref() {
+	p.refs--
+	if p.refs > 0 {
+		return
+	}
+	unix.Munmap(p.data)
+	for _, m := range p.retired {
+		unix.Munmap(m)
+	}
+	p.data, p.retired = nil, nil
+	unix.Close(p.fd)
+}
+
+// shmPoolImpl implements wl_shm_pool.
+type shmPoolImpl struct {
+	p *ShmPool
+}
+
+func (pi shmPoolImpl) CreateBuffer(Id gen.WlNewId, Offset gen.WlInt, Width gen.WlInt, Height gen.WlInt, Stride gen.WlInt, Format gen.WlUint) {
+	p := pi.p
+	format := gen.WlShmFormat(Format)
+	if !p.shm.Supports(format) {
+		p.postError(gen.WlShmInvalidFormat, "invalid format 0x%x", uint32(Format))
+		return
+	}
+	minStride := int64(Width) * int64(bytesPerPixel(format))
+	if minStride == 0 {
+		minStride = int64(Width)
+	}
+	if Offset < 0 || Width <= 0 || Height <= 0 || int64(Stride) < minStride ||
+		math.MaxInt32/int64(Stride) <= int64(Height) ||
+		int64(Offset) > int64(len(p.data))-int64(Stride)*int64(Height) {
+		p.postError(gen.WlShmInvalidStride, "invalid width, height or stride (%dx%d, %d)", Width, Height, Stride)
+		return
+	}
+	r, err := p.res.Client().NewResource(uint32(Id), gen.WlBufferInterface, p.res.Version())
+	if err != nil {
+		return
+	}
+	b := &ShmBuffer{
+		res:    r,
+		pool:   p,
+		offset: int(Offset),
+		width:  int(Width),
+		height: int(Height),
+		stride: int(Stride),
+		format: format,
+	}
+	p.refs++
+	r.SetImplementation(b)
+	r.AddDestroyListener(func(*Resource) { p.unref() })
+}
+
+func (pi shmPoolImpl) Resize(Size gen.WlInt) {
+	p := pi.p
+	if int(Size) < len(p.data) {
+		p.postError(gen.WlShmInvalidFd, "shrinking pool invalid")
+		return
+	}
+	if int(Size) == len(p.data) {
+		return
+	}
+	data, err := unix.Mmap(p.fd, 0, int(Size), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		p.postError(gen.WlShmInvalidFd, "failed mmap fd %d: %v", p.fd, err)
+		return
+	}
+	p.retired = append(p.retired, p.data)
+	p.data = data
+	p.checkSealed()
+}
+
+// A ShmBuffer is a wl_buffer created from a ShmPool.
+type ShmBuffer struct {
+	res    *Resource
+	pool   *ShmPool
+	offset int
+	width  int
+	height int
+	stride int
+	format gen.WlShmFormat
+}
+
+// ShmBufferFromResource returns the ShmBuffer behind a wl_buffer resource,
+// such as one attached to a surface, or nil if it is not a shm buffer.
+func ShmBufferFromResource(r *Resource) *ShmBuffer {
+	if r == nil {
+		return nil
+	}
+	b, _ := r.Implementation().(*ShmBuffer)
+	return b
+}
+
+// Resource returns the buffer's wl_buffer resource.
+func (b *ShmBuffer) Resource() *Resource {
+	return b.res
+}
+
+func (b *ShmBuffer) Width() int {
+	return b.width
+}
+
+func (b *ShmBuffer) Height() int {
+	return b.height
+}
+
+func (b *ShmBuffer) Stride() int {
+	return b.stride
+}
+
+func (b *ShmBuffer) Format() gen.WlShmFormat {
+	return b.format
+}
+
+// Data returns the buffer's bytes in the client's shared memory. The slice
+// stays valid until the buffer is destroyed, but the client may change its
+// contents at any time; compositors read it between a commit and the
+// buffer's release.
+//
+// The client may also truncate the file behind the pool, after which
+// reading the slice faults. Read it only inside Access.
+func (b *ShmBuffer) Data() []byte {
+	return b.pool.data[b.offset : b.offset+b.stride*b.height]
+}
+
+// ErrShmAccess is returned by Access when the buffer's memory could not be
+// read because the client truncated the file behind it.
+var ErrShmAccess = errors.New("error accessing SHM buffer")
+
+// Access calls f, which reads the buffer through Data or Image, and guards
+// those reads: if the client has truncated the pool's file, the resulting
+// fault is recovered, the client is sent a wl_shm.invalid_fd error and
+// disconnected, and Access returns ErrShmAccess. What f read before the
+// fault is unreliable.
+//
+// Faults outside the pool's memory are not recovered. Pools on memfds
+// sealed against shrinking cannot fault and are read without the guard.
+func (b *ShmBuffer) Access(f func()) (err error) {
+	p := b.pool
+	if p.sealed {
+		f()
+		return nil
+	}
+	old := debug.SetPanicOnFault(true)
+	defer func() {
+		debug.SetPanicOnFault(old)
+		v := recover()
+		if v == nil {
+			return
+		}
+		if fault, ok := v.(interface{ Addr() uintptr }); !ok || !p.mapped(fault.Addr()) {
+			panic(v)
+		}
+		p.postError(gen.WlShmInvalidFd, "error accessing SHM buffer")
+		err = ErrShmAccess
+	}()
+	f()
+	return nil
+}
+
+// Release sends wl_buffer.release, telling the client the compositor no
+// longer reads the buffer.
+func (b *ShmBuffer) Release() {
+	b.res.PostEvent(0)
+}
+
+// Image returns the buffer's pixels as an image aliasing its data, for the
+// 32-bit RGB formats. ARGB and ABGR data is premultiplied, as in Go's
+// color.RGBA; the X formats are read as opaque. As with Data, the image
+// should only be read inside Access.
+func (b *ShmBuffer) Image() (image.Image, error) {
+	rect := image.Rect(0, 0, b.width, b.height)
+	switch b.format {
+	case gen.WlShmAbgr8888:
+		return &image.RGBA{Pix: b.Data(), Stride: b.stride, Rect: rect}, nil
+	case gen.WlShmArgb8888, gen.WlShmXrgb8888, gen.WlShmXbgr8888:
+		return &shmImage{
+			pix:    b.Data(),
+			stride: b.stride,
+			rect:   rect,
+			bgr:    b.format != gen.WlShmXbgr8888,
+			opaque: b.format != gen.WlShmArgb8888,
+		}, nil
+	}
+	return nil, fmt.Errorf("Image: unsupported format 0x%x", uint32(b.format))
+}
+
+// shmImage reads 32-bit little-endian pixels: B, G, R, A in memory for the
+// ARGB formats and R, G, B, A for the ABGR ones.
+type shmImage struct {
+	pix    []byte
+	stride int
+	rect   image.Rectangle
+	bgr    bool
+	opaque bool
+}
+
+func (m *shmImage) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (m *shmImage) Bounds() image.Rectangle {
+	return m.rect
+}
+
+func (m *shmImage) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(m.rect)) {
+		return color.RGBA{}
+	}
+	p := m.pix[y*m.stride+x*4 : y*m.stride+x*4+4 : y*m.stride+x*4+4]
+	c := color.RGBA{R: p[0], G: p[1], B: p[2], A: p[3]}
+	if m.bgr {
+		c.R, c.B = c.B, c.R
+	}
+	if m.opaque {
+		c.A = 0xff
+	}
+	return c
+}
+
+// Opaque reports whether the image has no transparency.
+func (m *shmImage) Opaque() bool {
+	return m.opaque
+}
+
+// bytesPerPixel returns the size of a pixel of the packed formats, or 0 for
+// the planar and unusual ones.
+func bytesPerPixel(f gen.WlShmFormat) int {
+	switch f {
+	case gen.WlShmC8, gen.WlShmRgb332, gen.WlShmBgr233:
+		return 1
+	case gen.WlShmXrgb4444, gen.WlShmXbgr4444, gen.WlShmRgbx4444, gen.WlShmBgrx4444,
+		gen.WlShmArgb4444, gen.WlShmAbgr4444, gen.WlShmRgba4444, gen.WlShmBgra4444,
+		gen.WlShmXrgb1555, gen.WlShmXbgr1555, gen.WlShmRgbx5551, gen.WlShmBgrx5551,
+		gen.WlShmArgb1555, gen.WlShmAbgr1555, gen.WlShmRgba5551, gen.WlShmBgra5551,
+		gen.WlShmRgb565, gen.WlShmBgr565, gen.WlShmYuyv, gen.WlShmYvyu, gen.WlShmUyvy, gen.WlShmVyuy:
+		return 2
+	case gen.WlShmRgb888, gen.WlShmBgr888:
+		return 3
+	case gen.WlShmArgb8888, gen.WlShmXrgb8888, gen.WlShmXbgr8888, gen.WlShmRgbx8888,
+		gen.WlShmBgrx8888, gen.WlShmAbgr8888, gen.WlShmRgba8888, gen.WlShmBgra8888,
+		gen.WlShmXrgb2101010, gen.WlShmXbgr2101010, gen.WlShmRgbx1010102, gen.WlShmBgrx1010102,
+		gen.WlShmArgb2101010, gen.WlShmAbgr2101010, gen.WlShmRgba1010102, gen.WlShmBgra1010102,
+		gen.WlShmAyuv:
+		return 4
+	}
+	return 0
+}
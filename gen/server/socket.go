@@ -0,0 +1,140 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/Pursuit92/goland/gen"
+	"golang.org/x/sys/unix"
+)
+
+// The highest N tried by ListenAuto, as in libwayland.
+const maxAutoDisplay = 32
+
+// ErrSocketInUse is returned by Listen when another server holds the lock
+// for the socket.
+var ErrSocketInUse = errors.New("display socket is in use")
+
+// A Socket is a listening display socket. Sockets created by Listen are
+// guarded by a lock file next to them, following libwayland: whoever holds
+// the flock on "<socket>.lock" owns the socket path, so a socket left behind
+// by a server that died can be told from one that is still being served and
+// is safely removed.
+type Socket struct {
+	*net.UnixListener
+
+	path     string
+	lockPath string
+	lock     *os.File
+}
+
+// Listen creates the display socket called name, resolved as by
+// gen.SocketPath.
+func Listen(name string) (*Socket, error) {
+	path, err := gen.SocketPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Socket{path: path, lockPath: path + ".lock"}
+	s.lock, err = os.OpenFile(s.lockPath, os.O_CREATE|os.O_RDWR, 0660)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(s.lock.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		s.lock.Close()
+		if err == unix.EWOULDBLOCK {
+			return nil, fmt.Errorf("Listen: %s: %w", path, ErrSocketInUse)
+		}
+		return nil, err
+	}
+
+	// Holding the lock means nobody serves the socket, so whatever is left
+	// at the path is stale.
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		s.unlock()
+		return nil, err
+	}
+	s.UnixListener, err = net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		s.unlock()
+		return nil, err
+	}
+	// The socket and lock files are removed by Close, not by the listener.
+	s.UnixListener.SetUnlinkOnClose(false)
+	return s, nil
+}
+
+// ListenAuto creates the first free display socket among wayland-0 to
+// wayland-32 in XDG_RUNTIME_DIR.
+func ListenAuto() (*Socket, error) {
+	for n := 0; n <= maxAutoDisplay; n++ {
+		s, err := Listen(fmt.Sprintf("wayland-%d", n))
+		if errors.Is(err, ErrSocketInUse) {
+			continue
+		}
+		return s, err
+	}
+	return nil, errors.New("ListenAuto: no free display socket")
+}
+
+// FileListener wraps a listening socket that was opened by someone else,
+// such as a socket passed in by a service manager for socket activation.
+// Closing it does not remove anything from the filesystem.
+func FileListener(l *net.UnixListener) *Socket {
+	l.SetUnlinkOnClose(false)
+	return &Socket{UnixListener: l}
+}
+
+// FDListener is like FileListener, but takes the listening socket's file
+// descriptor.
+func FDListener(fd int) (*Socket, error) {
+	f := os.NewFile(uintptr(fd), "listener")
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, err
+	}
+	ul, ok := l.(*net.UnixListener)
+	if !ok {
+		l.Close()
+		return nil, errors.New("FDListener: not a unix socket")
+	}
+	return FileListener(ul), nil
+}
+
+// Path returns the filesystem path of the socket, or "" if it was not
+// created by Listen.
+func (s *Socket) Path() string {
+	return s.path
+}
+
+// Name returns the name clients pass in WAYLAND_DISPLAY to reach the socket.
+func (s *Socket) Name() string {
+	if s.path == "" {
+		return ""
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && filepath.Dir(s.path) == filepath.Clean(dir) {
+		return filepath.Base(s.path)
+	}
+	return s.path
+}
+
+// Close stops listening and removes the socket and its lock file.
+func (s *Socket) Close() error {
+	err := s.UnixListener.Close()
+	if s.lock != nil {
+		os.Remove(s.path)
+		s.unlock()
+	}
+	return err
+}
+
+func (s *Socket) unlock() {
+	os.Remove(s.lockPath)
+	s.lock.Close()
+	s.lock = nil
+}
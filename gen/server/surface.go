@@ -0,0 +1,525 @@
+package server
+
+import (
+	"image"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/region"
+)
+
+// A Surface implements wl_surface's double-buffered state as described in
+// gen.WlSurface: requests change the pending state, and commit makes it
+// current, the attached buffer first and the rest second. What the surface
+// is for is up to its role, which other interfaces give it; the Surface
+// only keeps track of the role's name, so that a surface never gets a
+// second one.
+//
+// Shells and other protocol extensions add double-buffered state of their
+// own with AddExtension, and learn about commits through their role object.
+type Surface struct {
+	res *Resource
+
+	pending surfaceState
+	// cached holds commits of a synchronized subsurface until its parent's
+	// commit applies them.
+	cached    surfaceState
+	hasCached bool
+	current   surfaceState
+	// attached is whether the last commit came with wl_surface.attach, and
+	// released whether the current buffer has been released.
+	attached, released bool
+	width, height      int
+	frames             []*Resource
+	outputs            []*Output
+
+	role            string
+	roleObject      SurfaceRole
+	extensions      []SurfaceExtension
+	commitListeners []func(*Surface)
+	userData        interface{}
+}
+
+// surfaceState is the state requests change and commit applies.
+type surfaceState struct {
+	buffer   *Resource
+	attached bool
+	dx, dy   int
+	damage   region.Region
+	frames   []*Resource
+
+	opaque, input       region.Region
+	opaqueSet, inputSet bool
+	scale               int32
+	transform           gen.WlOutputTransform
+}
+
+// merge moves the state in src into s, as a second commit on top of the
+// one s holds, and resets src.
+func (s *surfaceState) merge(src *surfaceState) {
+	if src.attached {
+		s.buffer, s.attached = src.buffer, true
+		s.dx += src.dx
+		s.dy += src.dy
+	}
+	s.damage = s.damage.Union(src.damage)
+	s.frames = append(s.frames, src.frames...)
+	if src.opaqueSet {
+		s.opaque, s.opaqueSet = src.opaque, true
+	}
+	if src.inputSet {
+		s.input, s.inputSet = src.input, true
+	}
+	s.scale, s.transform = src.scale, src.transform
+	src.reset()
+}
+
+// reset empties the state after a commit. Buffer scale and transform stay,
+// as they are not reset by commit.
+func (s *surfaceState) reset() {
+	*s = surfaceState{scale: s.scale, transform: s.transform}
+}
+
+// A SurfaceRole is the object playing a surface's role, such as a shell
+// surface or a subsurface. It sees every commit of the surface once the new
+// state is current.
+//
+// If the role object also has a method Synchronized() bool and it reports
+// true, commits are cached instead of applied until ApplyCached is called.
+// This is how a synchronized wl_subsurface waits for its parent's commit.
+type SurfaceRole interface {
+	Committed(s *Surface)
+}
+
+// A SurfaceExtension keeps double-buffered state of its own for a surface,
+// which moves along with the surface's: on commit the surface calls Cache
+// and then, unless the commit is held back for a synchronized subsurface,
+// Apply.
+type SurfaceExtension interface {
+	// Cache merges the pending state into the cached state, which may
+	// already hold held-back commits, and resets the pending state.
+	Cache()
+	// Apply makes the cached state current and empties it.
+	Apply()
+}
+
+type synchronizer interface {
+	Synchronized() bool
+}
+
+// NewSurface implements wl_surface on r, as a compositor does from
+// wl_compositor.create_surface.
+func NewSurface(r *Resource) *Surface {
+	s := &Surface{res: r, released: true}
+	s.pending.scale, s.cached.scale, s.current.scale = 1, 1, 1
+	s.current.input = region.Infinite()
+	r.SetImplementation(surfaceImpl{s})
+	r.SetUserData(s)
+	r.AddDestroyListener(func(*Resource) { s.destroyed() })
+	return s
+}
+
+// destroyed drops the frame callbacks that will never be completed, and
+// takes the surface off its outputs.
+func (s *Surface) destroyed() {
+	for _, list := range [][]*Resource{s.pending.frames, s.cached.frames, s.frames} {
+		for _, cb := range list {
+			cb.Destroy()
+		}
+	}
+	s.pending.reset()
+	s.cached.reset()
+	s.frames = nil
+	for _, o := range s.outputs {
+		o.surfaces = removeSurface(o.surfaces, s)
+	}
+	s.outputs = nil
+}
+
+// SurfaceFromResource returns the Surface behind a wl_surface resource.
+func SurfaceFromResource(r *Resource) *Surface {
+	if r == nil {
+		return nil
+	}
+	s, _ := r.UserData().(*Surface)
+	return s
+}
+
+// Resource returns the surface's wl_surface resource.
+func (s *Surface) Resource() *Resource {
+	return s.res
+}
+
+// UserData returns the value set with SetUserData.
+func (s *Surface) UserData() interface{} {
+	return s.userData
+}
+
+// SetUserData attaches an arbitrary value to the surface, such as the
+// compositor's own record of it. The resource's user data is the Surface.
+func (s *Surface) SetUserData(v interface{}) {
+	s.userData = v
+}
+
+// Role returns the name of the surface's role, or "" if it has none yet.
+func (s *Surface) Role() string {
+	return s.role
+}
+
+// SetRole gives the surface a role. A surface keeps its role for life, and
+// giving it the same role again is allowed. Giving it a different role
+// fails: the error code of the interface whose request tried it is posted
+// on errRes and SetRole returns false.
+func (s *Surface) SetRole(role string, errRes *Resource, code uint32) bool {
+	if s.role == "" || s.role == role {
+		s.role = role
+		return true
+	}
+	errRes.PostError(code, "wl_surface@%d already has another role assigned", s.res.Id())
+	return false
+}
+
+// RoleObject returns the object playing the surface's role.
+func (s *Surface) RoleObject() SurfaceRole {
+	return s.roleObject
+}
+
+// SetRoleObject sets the object playing the surface's role. When it is
+// destroyed it should be set to nil: the surface keeps its role, but
+// nothing plays it.
+func (s *Surface) SetRoleObject(r SurfaceRole) {
+	s.roleObject = r
+}
+
+// AddExtension adds double-buffered state to the surface.
+func (s *Surface) AddExtension(e SurfaceExtension) {
+	s.extensions = append(s.extensions, e)
+}
+
+// RemoveExtension removes state added with AddExtension, as when the
+// extension's object is destroyed.
+func (s *Surface) RemoveExtension(e SurfaceExtension) {
+	for i, v := range s.extensions {
+		if v == e {
+			s.extensions = append(s.extensions[:i], s.extensions[i+1:]...)
+			return
+		}
+	}
+}
+
+// AddCommitListener registers f to be called after each commit that is
+// applied, before the role object sees it, so that the compositor can take
+// up the new contents first.
+func (s *Surface) AddCommitListener(f func(*Surface)) {
+	s.commitListeners = append(s.commitListeners, f)
+}
+
+// Buffer returns the current buffer, or nil if the surface has no content
+// or the client destroyed the buffer.
+func (s *Surface) Buffer() *Resource {
+	if b := s.current.buffer; b != nil && !b.Destroyed() {
+		return b
+	}
+	return nil
+}
+
+// Attached reports whether the last commit attached a buffer, possibly the
+// same one or none, so that the contents must be read again.
+func (s *Surface) Attached() bool {
+	return s.attached
+}
+
+// BufferOffset returns the x and y given to the attach of the last commit
+// that had one: how far the new buffer's top-left corner moved, in surface
+// local coordinates.
+func (s *Surface) BufferOffset() (dx, dy int) {
+	return s.current.dx, s.current.dy
+}
+
+// ReleaseBuffer sends wl_buffer.release for the current buffer, once the
+// compositor has stopped reading it. A buffer that is replaced by a commit
+// is released then if it was not before.
+func (s *Surface) ReleaseBuffer() {
+	if !s.released {
+		s.released = true
+		if b := s.Buffer(); b != nil {
+			b.PostEvent(0)
+		}
+	}
+}
+
+// Size returns the size of the surface in surface local coordinates: that
+// of its buffer, rotated by the buffer transform and divided by the buffer
+// scale.
+func (s *Surface) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Scale returns the current buffer scale.
+func (s *Surface) Scale() int32 {
+	return s.current.scale
+}
+
+// Transform returns the current buffer transform.
+func (s *Surface) Transform() gen.WlOutputTransform {
+	return s.current.transform
+}
+
+// Damage returns the damage accumulated since the last ClearDamage, clipped
+// to the surface.
+func (s *Surface) Damage() region.Region {
+	return s.current.damage.IntersectRect(image.Rect(0, 0, s.width, s.height))
+}
+
+// ClearDamage forgets the current damage, once the compositor has
+// repainted it.
+func (s *Surface) ClearDamage() {
+	s.current.damage = region.Region{}
+}
+
+// OpaqueRegion returns the current opaque region, empty unless the client
+// set one.
+func (s *Surface) OpaqueRegion() region.Region {
+	return s.current.opaque
+}
+
+// InputRegion returns the current input region, infinite unless the client
+// set one.
+func (s *Surface) InputRegion() region.Region {
+	return s.current.input
+}
+
+// AcceptsInput reports whether the point, in surface local coordinates, is
+// on the surface and in its input region.
+func (s *Surface) AcceptsInput(x, y int) bool {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return false
+	}
+	return s.current.input.Contains(x, y)
+}
+
+// SendFrameDone completes the frame callbacks of the commits applied so
+// far, in the order they were requested, with time in milliseconds.
+func (s *Surface) SendFrameDone(time uint32) {
+	frames := s.frames
+	s.frames = nil
+	for _, cb := range frames {
+		if !cb.Destroyed() {
+			cb.PostEvent(0, gen.WlUint(time))
+			cb.Destroy()
+		}
+	}
+}
+
+// HasFrameCallbacks reports whether frame callbacks are waiting for
+// SendFrameDone.
+func (s *Surface) HasFrameCallbacks() bool {
+	return len(s.frames) > 0
+}
+
+// Outputs returns the outputs the surface has entered.
+func (s *Surface) Outputs() []*Output {
+	return append([]*Output(nil), s.outputs...)
+}
+
+// EnterOutput records that the surface is shown on o, and sends
+// wl_surface.enter for each of the client's wl_output objects for o. A
+// client that binds o later gets enter as it binds.
+func (s *Surface) EnterOutput(o *Output) {
+	if o.removed {
+		return
+	}
+	for _, v := range s.outputs {
+		if v == o {
+			return
+		}
+	}
+	s.outputs = append(s.outputs, o)
+	o.surfaces = append(o.surfaces, s)
+	for _, r := range o.Resources(s.res.Client()) {
+		s.res.PostEvent(0, gen.WlObject(r.Id()))
+	}
+}
+
+// LeaveOutput records that the surface is no longer shown on o, and sends
+// wl_surface.leave if it had entered it.
+func (s *Surface) LeaveOutput(o *Output) {
+	for i, v := range s.outputs {
+		if v == o {
+			s.outputs = append(s.outputs[:i], s.outputs[i+1:]...)
+			o.surfaces = removeSurface(o.surfaces, s)
+			for _, r := range o.Resources(s.res.Client()) {
+				s.res.PostEvent(1, gen.WlObject(r.Id()))
+			}
+			return
+		}
+	}
+}
+
+func removeSurface(list []*Surface, s *Surface) []*Surface {
+	for i, v := range list {
+		if v == s {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// commit handles wl_surface.commit.
+func (s *Surface) commit() {
+	s.cached.merge(&s.pending)
+	for _, e := range s.extensions {
+		e.Cache()
+	}
+	s.hasCached = true
+	if sync, ok := s.roleObject.(synchronizer); ok && sync.Synchronized() {
+		return
+	}
+	s.ApplyCached()
+}
+
+// ApplyCached applies the commits held back while the surface was
+// synchronized, if there are any. The role object calls it when the
+// parent's commit should apply them, or when the surface stops being
+// synchronized.
+func (s *Surface) ApplyCached() {
+	if !s.hasCached {
+		return
+	}
+	s.hasCached = false
+	c := &s.cached
+	cur := &s.current
+
+	// The buffer comes first, so that the rest is relative to it.
+	s.attached = c.attached
+	if c.attached {
+		buf := c.buffer
+		if buf != nil && buf.Destroyed() {
+			buf = nil
+		}
+		if buf != cur.buffer {
+			s.ReleaseBuffer()
+		}
+		cur.buffer = buf
+		s.released = buf == nil
+		cur.dx, cur.dy = c.dx, c.dy
+	}
+	cur.scale, cur.transform = c.scale, c.transform
+	s.width, s.height = s.bufferSize()
+
+	cur.damage = cur.damage.Union(c.damage)
+	s.frames = append(s.frames, c.frames...)
+	if c.opaqueSet {
+		cur.opaque = c.opaque
+	}
+	if c.inputSet {
+		cur.input = c.input
+	}
+	c.reset()
+
+	for _, e := range s.extensions {
+		e.Apply()
+	}
+	for _, f := range s.commitListeners {
+		f(s)
+	}
+	if s.roleObject != nil {
+		s.roleObject.Committed(s)
+	}
+}
+
+// bufferSize computes the surface size from the current buffer. Buffers
+// other than shm buffers report their size with Width and Height methods
+// on their implementation.
+func (s *Surface) bufferSize() (width, height int) {
+	b := s.Buffer()
+	if b == nil {
+		return 0, 0
+	}
+	sized, ok := b.Implementation().(interface {
+		Width() int
+		Height() int
+	})
+	if !ok {
+		return 0, 0
+	}
+	width, height = sized.Width(), sized.Height()
+	if s.current.transform&1 != 0 {
+		width, height = height, width
+	}
+	scale := int(s.current.scale)
+	return width / scale, height / scale
+}
+
+// surfaceImpl implements wl_surface.
+type surfaceImpl struct {
+	s *Surface
+}
+
+func (si surfaceImpl) Attach(Buffer gen.WlObject, X gen.WlInt, Y gen.WlInt) {
+	s := si.s
+	s.pending.buffer = nil
+	if Buffer != 0 {
+		s.pending.buffer = s.res.Client().Resource(uint32(Buffer))
+	}
+	s.pending.attached = true
+	s.pending.dx, s.pending.dy = int(X), int(Y)
+}
+
+func (si surfaceImpl) Damage(X gen.WlInt, Y gen.WlInt, Width gen.WlInt, Height gen.WlInt) {
+	s := si.s
+	if rect, ok := rectArgs(X, Y, Width, Height); ok {
+		s.pending.damage = s.pending.damage.UnionRect(rect)
+	}
+}
+
+func (si surfaceImpl) Frame(Callback gen.WlNewId) {
+	s := si.s
+	cb, err := s.res.Client().NewResource(uint32(Callback), gen.WlCallbackInterface, 1)
+	if err != nil {
+		return
+	}
+	s.pending.frames = append(s.pending.frames, cb)
+}
+
+func (si surfaceImpl) SetOpaqueRegion(Region gen.WlObject) {
+	s := si.s
+	s.pending.opaque = s.regionArg(Region, region.Region{})
+	s.pending.opaqueSet = true
+}
+
+func (si surfaceImpl) SetInputRegion(Region gen.WlObject) {
+	s := si.s
+	s.pending.input = s.regionArg(Region, region.Infinite())
+	s.pending.inputSet = true
+}
+
+// regionArg returns the region named by a wl_region argument as it is
+// now, or null for a null one.
+func (s *Surface) regionArg(id gen.WlObject, null region.Region) region.Region {
+	if r := RegionFromResource(s.res.Client().Resource(uint32(id))); r != nil {
+		return *r
+	}
+	return null
+}
+
+func (si surfaceImpl) Commit() {
+	si.s.commit()
+}
+
+func (si surfaceImpl) SetBufferTransform(Transform gen.WlInt) {
+	s := si.s
+	if Transform < gen.WlInt(gen.WlOutputNormal) || Transform > gen.WlInt(gen.WlOutputFlipped270) {
+		s.res.PostError(uint32(gen.WlSurfaceInvalidTransform), "buffer transform value is invalid: %d", Transform)
+		return
+	}
+	s.pending.transform = gen.WlOutputTransform(Transform)
+}
+
+func (si surfaceImpl) SetBufferScale(Scale gen.WlInt) {
+	s := si.s
+	if Scale < 1 {
+		s.res.PostError(uint32(gen.WlSurfaceInvalidScale), "buffer scale must be at least one (%d specified)", Scale)
+		return
+	}
+	s.pending.scale = int32(Scale)
+}
@@ -0,0 +1,41 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// The socket name used when neither the caller nor WAYLAND_DISPLAY names one.
+const defaultDisplay = "wayland-0"
+
+// SocketPath returns the filesystem path of the display socket called name.
+// An empty name stands for WAYLAND_DISPLAY, or "wayland-0" if that is unset
+// too. Absolute names are used as they are; relative ones are looked up in
+// XDG_RUNTIME_DIR.
+func SocketPath(name string) (string, error) {
+	if name == "" {
+		name = os.Getenv("WAYLAND_DISPLAY")
+	}
+	if name == "" {
+		name = defaultDisplay
+	}
+
+	path := name
+	if !filepath.IsAbs(name) {
+		runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+		if runtimeDir == "" {
+			return "", errors.New("SocketPath: XDG_RUNTIME_DIR is not set")
+		}
+		path = filepath.Join(runtimeDir, name)
+	}
+
+	// sun_path is a fixed size array that must also hold the terminating NUL.
+	if len(path) >= len(unix.RawSockaddrUnix{}.Path) {
+		return "", fmt.Errorf("SocketPath: socket path %q is too long", path)
+	}
+	return path, nil
+}
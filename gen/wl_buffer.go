@@ -23,3 +23,21 @@ type WlBuffer interface {
 	// For possible side-effects to a surface, see wl_surface.attach.
 	Destroy()
 }
+
+// WlBufferReleaseEvent holds the arguments of a wl_buffer.release event.
+type WlBufferReleaseEvent struct {
+}
+
+var WlBufferInterface = &Interface{
+	Name:    "wl_buffer",
+	Version: 1,
+	Requests: []Message{
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+	},
+	Events: []Message{
+		{Name: "release", Since: 1, Args: []Arg{}, Event: WlBufferReleaseEvent{}},
+	},
+	Enums: []Enum{},
+}
+
+func init() { registerInterface(WlBufferInterface) }
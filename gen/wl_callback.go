@@ -6,3 +6,22 @@ type WlCallback interface {
 	// Notify the client when the related request is done.
 	Done(CallbackData WlUint)
 }
+
+// WlCallbackDoneEvent holds the arguments of a wl_callback.done event.
+type WlCallbackDoneEvent struct {
+	CallbackData WlUint
+}
+
+var WlCallbackInterface = &Interface{
+	Name:     "wl_callback",
+	Version:  1,
+	Requests: []Message{},
+	Events: []Message{
+		{Name: "done", Since: 1, Args: []Arg{
+			{Name: "callback_data", Type: ArgUint},
+		}, Event: WlCallbackDoneEvent{}},
+	},
+	Enums: []Enum{},
+}
+
+func init() { registerInterface(WlCallbackInterface) }
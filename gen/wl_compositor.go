@@ -9,3 +9,20 @@ type WlCompositor interface {
 	// Ask the compositor to create a new region.
 	CreateRegion(Id WlNewId)
 }
+
+var WlCompositorInterface = &Interface{
+	Name:    "wl_compositor",
+	Version: 3,
+	Requests: []Message{
+		{Name: "create_surface", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_surface"},
+		}},
+		{Name: "create_region", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_region"},
+		}},
+	},
+	Events: []Message{},
+	Enums:  []Enum{},
+}
+
+func init() { registerInterface(WlCompositorInterface) }
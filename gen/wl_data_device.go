@@ -73,8 +73,89 @@ type WlDataDevice interface {
 	// This request destroys the data device.
 	Release()
 }
+
+// WlDataDeviceDataOfferEvent holds the arguments of a wl_data_device.data_offer event.
+type WlDataDeviceDataOfferEvent struct {
+	Id WlNewId
+}
+
+// WlDataDeviceEnterEvent holds the arguments of a wl_data_device.enter event.
+type WlDataDeviceEnterEvent struct {
+	Serial  WlUint
+	Surface WlObject
+	X       WlFixed
+	Y       WlFixed
+	Id      WlObject
+}
+
+// WlDataDeviceLeaveEvent holds the arguments of a wl_data_device.leave event.
+type WlDataDeviceLeaveEvent struct {
+}
+
+// WlDataDeviceMotionEvent holds the arguments of a wl_data_device.motion event.
+type WlDataDeviceMotionEvent struct {
+	Time WlUint
+	X    WlFixed
+	Y    WlFixed
+}
+
+// WlDataDeviceDropEvent holds the arguments of a wl_data_device.drop event.
+type WlDataDeviceDropEvent struct {
+}
+
+// WlDataDeviceSelectionEvent holds the arguments of a wl_data_device.selection event.
+type WlDataDeviceSelectionEvent struct {
+	Id WlObject
+}
 type WlDataDeviceError uint32
 
 const (
 	WlDataDeviceRole WlDataDeviceError = 0
 )
+
+var WlDataDeviceInterface = &Interface{
+	Name:    "wl_data_device",
+	Version: 2,
+	Requests: []Message{
+		{Name: "start_drag", Since: 1, Args: []Arg{
+			{Name: "source", Type: ArgObject, Interface: "wl_data_source", AllowNull: true},
+			{Name: "origin", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "icon", Type: ArgObject, Interface: "wl_surface", AllowNull: true},
+			{Name: "serial", Type: ArgUint},
+		}},
+		{Name: "set_selection", Since: 1, Args: []Arg{
+			{Name: "source", Type: ArgObject, Interface: "wl_data_source", AllowNull: true},
+			{Name: "serial", Type: ArgUint},
+		}},
+		{Name: "release", Since: 2, Destructor: true, Args: []Arg{}},
+	},
+	Events: []Message{
+		{Name: "data_offer", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_data_offer"},
+		}, Event: WlDataDeviceDataOfferEvent{}},
+		{Name: "enter", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "x", Type: ArgFixed},
+			{Name: "y", Type: ArgFixed},
+			{Name: "id", Type: ArgObject, Interface: "wl_data_offer", AllowNull: true},
+		}, Event: WlDataDeviceEnterEvent{}},
+		{Name: "leave", Since: 1, Args: []Arg{}, Event: WlDataDeviceLeaveEvent{}},
+		{Name: "motion", Since: 1, Args: []Arg{
+			{Name: "time", Type: ArgUint},
+			{Name: "x", Type: ArgFixed},
+			{Name: "y", Type: ArgFixed},
+		}, Event: WlDataDeviceMotionEvent{}},
+		{Name: "drop", Since: 1, Args: []Arg{}, Event: WlDataDeviceDropEvent{}},
+		{Name: "selection", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgObject, Interface: "wl_data_offer", AllowNull: true},
+		}, Event: WlDataDeviceSelectionEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "role", Value: 0},
+		}},
+	},
+}
+
+func init() { registerInterface(WlDataDeviceInterface) }
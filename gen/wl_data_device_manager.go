@@ -11,3 +11,21 @@ type WlDataDeviceManager interface {
 	// Create a new data device for a given seat.
 	GetDataDevice(Id WlNewId, Seat WlObject)
 }
+
+var WlDataDeviceManagerInterface = &Interface{
+	Name:    "wl_data_device_manager",
+	Version: 2,
+	Requests: []Message{
+		{Name: "create_data_source", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_data_source"},
+		}},
+		{Name: "get_data_device", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_data_device"},
+			{Name: "seat", Type: ArgObject, Interface: "wl_seat"},
+		}},
+	},
+	Events: []Message{},
+	Enums:  []Enum{},
+}
+
+func init() { registerInterface(WlDataDeviceManagerInterface) }
@@ -27,3 +27,32 @@ type WlDataOffer interface {
 	// Destroy the data offer.
 	Destroy()
 }
+
+// WlDataOfferOfferEvent holds the arguments of a wl_data_offer.offer event.
+type WlDataOfferOfferEvent struct {
+	MimeType WlString
+}
+
+var WlDataOfferInterface = &Interface{
+	Name:    "wl_data_offer",
+	Version: 1,
+	Requests: []Message{
+		{Name: "accept", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "mime_type", Type: ArgString, AllowNull: true},
+		}},
+		{Name: "receive", Since: 1, Args: []Arg{
+			{Name: "mime_type", Type: ArgString},
+			{Name: "fd", Type: ArgFd},
+		}},
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+	},
+	Events: []Message{
+		{Name: "offer", Since: 1, Args: []Arg{
+			{Name: "mime_type", Type: ArgString},
+		}, Event: WlDataOfferOfferEvent{}},
+	},
+	Enums: []Enum{},
+}
+
+func init() { registerInterface(WlDataOfferInterface) }
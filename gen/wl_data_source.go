@@ -23,3 +23,42 @@ type WlDataSource interface {
 	// Destroy the data source.
 	Destroy()
 }
+
+// WlDataSourceTargetEvent holds the arguments of a wl_data_source.target event.
+type WlDataSourceTargetEvent struct {
+	MimeType WlString
+}
+
+// WlDataSourceSendEvent holds the arguments of a wl_data_source.send event.
+type WlDataSourceSendEvent struct {
+	MimeType WlString
+	Fd       WlFd
+}
+
+// WlDataSourceCancelledEvent holds the arguments of a wl_data_source.cancelled event.
+type WlDataSourceCancelledEvent struct {
+}
+
+var WlDataSourceInterface = &Interface{
+	Name:    "wl_data_source",
+	Version: 1,
+	Requests: []Message{
+		{Name: "offer", Since: 1, Args: []Arg{
+			{Name: "mime_type", Type: ArgString},
+		}},
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+	},
+	Events: []Message{
+		{Name: "target", Since: 1, Args: []Arg{
+			{Name: "mime_type", Type: ArgString, AllowNull: true},
+		}, Event: WlDataSourceTargetEvent{}},
+		{Name: "send", Since: 1, Args: []Arg{
+			{Name: "mime_type", Type: ArgString},
+			{Name: "fd", Type: ArgFd},
+		}, Event: WlDataSourceSendEvent{}},
+		{Name: "cancelled", Since: 1, Args: []Arg{}, Event: WlDataSourceCancelledEvent{}},
+	},
+	Enums: []Enum{},
+}
+
+func init() { registerInterface(WlDataSourceInterface) }
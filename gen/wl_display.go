@@ -33,6 +33,18 @@ type WlDisplay interface {
 	GetRegistry(Registry WlNewId)
 }
 
+// WlDisplayErrorEvent holds the arguments of a wl_display.error event.
+type WlDisplayErrorEvent struct {
+	ObjectId WlObject
+	Code     WlUint
+	Message  WlString
+}
+
+// WlDisplayDeleteIdEvent holds the arguments of a wl_display.delete_id event.
+type WlDisplayDeleteIdEvent struct {
+	Id WlUint
+}
+
 // These errors are global and can be emitted in response to any
 // server request.
 type WlDisplayError uint32
@@ -42,3 +54,35 @@ const (
 	WlDisplayInvalidMethod WlDisplayError = 1
 	WlDisplayNoMemory      WlDisplayError = 2
 )
+
+var WlDisplayInterface = &Interface{
+	Name:    "wl_display",
+	Version: 1,
+	Requests: []Message{
+		{Name: "sync", Since: 1, Args: []Arg{
+			{Name: "callback", Type: ArgNewId, Interface: "wl_callback"},
+		}},
+		{Name: "get_registry", Since: 1, Args: []Arg{
+			{Name: "registry", Type: ArgNewId, Interface: "wl_registry"},
+		}},
+	},
+	Events: []Message{
+		{Name: "error", Since: 1, Args: []Arg{
+			{Name: "object_id", Type: ArgObject},
+			{Name: "code", Type: ArgUint},
+			{Name: "message", Type: ArgString},
+		}, Event: WlDisplayErrorEvent{}},
+		{Name: "delete_id", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgUint},
+		}, Event: WlDisplayDeleteIdEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "invalid_object", Value: 0},
+			{Name: "invalid_method", Value: 1},
+			{Name: "no_memory", Value: 2},
+		}},
+	},
+}
+
+func init() { registerInterface(WlDisplayInterface) }
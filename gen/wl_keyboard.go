@@ -34,6 +34,49 @@ type WlKeyboard interface {
 	Release()
 }
 
+// WlKeyboardKeymapEvent holds the arguments of a wl_keyboard.keymap event.
+type WlKeyboardKeymapEvent struct {
+	Format WlUint
+	Fd     WlFd
+	Size   WlUint
+}
+
+// WlKeyboardEnterEvent holds the arguments of a wl_keyboard.enter event.
+type WlKeyboardEnterEvent struct {
+	Serial  WlUint
+	Surface WlObject
+	Keys    WlArray
+}
+
+// WlKeyboardLeaveEvent holds the arguments of a wl_keyboard.leave event.
+type WlKeyboardLeaveEvent struct {
+	Serial  WlUint
+	Surface WlObject
+}
+
+// WlKeyboardKeyEvent holds the arguments of a wl_keyboard.key event.
+type WlKeyboardKeyEvent struct {
+	Serial WlUint
+	Time   WlUint
+	Key    WlUint
+	State  WlUint
+}
+
+// WlKeyboardModifiersEvent holds the arguments of a wl_keyboard.modifiers event.
+type WlKeyboardModifiersEvent struct {
+	Serial        WlUint
+	ModsDepressed WlUint
+	ModsLatched   WlUint
+	ModsLocked    WlUint
+	Group         WlUint
+}
+
+// WlKeyboardRepeatInfoEvent holds the arguments of a wl_keyboard.repeat_info event.
+type WlKeyboardRepeatInfoEvent struct {
+	Rate  WlInt
+	Delay WlInt
+}
+
 // This specifies the format of the keymap provided to the
 // client with the wl_keyboard.keymap event.
 type WlKeyboardKeymapFormat uint32
@@ -50,3 +93,56 @@ const (
 	WlKeyboardReleased WlKeyboardKeyState = 0
 	WlKeyboardPressed  WlKeyboardKeyState = 1
 )
+
+var WlKeyboardInterface = &Interface{
+	Name:    "wl_keyboard",
+	Version: 4,
+	Requests: []Message{
+		{Name: "release", Since: 3, Destructor: true, Args: []Arg{}},
+	},
+	Events: []Message{
+		{Name: "keymap", Since: 1, Args: []Arg{
+			{Name: "format", Type: ArgUint},
+			{Name: "fd", Type: ArgFd},
+			{Name: "size", Type: ArgUint},
+		}, Event: WlKeyboardKeymapEvent{}},
+		{Name: "enter", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "keys", Type: ArgArray},
+		}, Event: WlKeyboardEnterEvent{}},
+		{Name: "leave", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+		}, Event: WlKeyboardLeaveEvent{}},
+		{Name: "key", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "time", Type: ArgUint},
+			{Name: "key", Type: ArgUint},
+			{Name: "state", Type: ArgUint},
+		}, Event: WlKeyboardKeyEvent{}},
+		{Name: "modifiers", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "mods_depressed", Type: ArgUint},
+			{Name: "mods_latched", Type: ArgUint},
+			{Name: "mods_locked", Type: ArgUint},
+			{Name: "group", Type: ArgUint},
+		}, Event: WlKeyboardModifiersEvent{}},
+		{Name: "repeat_info", Since: 4, Args: []Arg{
+			{Name: "rate", Type: ArgInt},
+			{Name: "delay", Type: ArgInt},
+		}, Event: WlKeyboardRepeatInfoEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "keymap_format", Entries: []EnumEntry{
+			{Name: "no_keymap", Value: 0},
+			{Name: "xkb_v1", Value: 1},
+		}},
+		{Name: "key_state", Entries: []EnumEntry{
+			{Name: "released", Value: 0},
+			{Name: "pressed", Value: 1},
+		}},
+	},
+}
+
+func init() { registerInterface(WlKeyboardInterface) }
@@ -48,6 +48,35 @@ type WlOutput interface {
 	Scale(Factor WlInt)
 }
 
+// WlOutputGeometryEvent holds the arguments of a wl_output.geometry event.
+type WlOutputGeometryEvent struct {
+	X              WlInt
+	Y              WlInt
+	PhysicalWidth  WlInt
+	PhysicalHeight WlInt
+	Subpixel       WlInt
+	Make           WlString
+	Model          WlString
+	Transform      WlInt
+}
+
+// WlOutputModeEvent holds the arguments of a wl_output.mode event.
+type WlOutputModeEvent struct {
+	Flags   WlUint
+	Width   WlInt
+	Height  WlInt
+	Refresh WlInt
+}
+
+// WlOutputDoneEvent holds the arguments of a wl_output.done event.
+type WlOutputDoneEvent struct {
+}
+
+// WlOutputScaleEvent holds the arguments of a wl_output.scale event.
+type WlOutputScaleEvent struct {
+	Factor WlInt
+}
+
 // This enumeration describes how the physical
 // pixels on an output are laid out.
 type WlOutputSubpixel uint32
@@ -91,3 +120,57 @@ const (
 	WlOutputCurrent   WlOutputMode = 0x1
 	WlOutputPreferred WlOutputMode = 0x2
 )
+
+var WlOutputInterface = &Interface{
+	Name:     "wl_output",
+	Version:  2,
+	Requests: []Message{},
+	Events: []Message{
+		{Name: "geometry", Since: 1, Args: []Arg{
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+			{Name: "physical_width", Type: ArgInt},
+			{Name: "physical_height", Type: ArgInt},
+			{Name: "subpixel", Type: ArgInt},
+			{Name: "make", Type: ArgString},
+			{Name: "model", Type: ArgString},
+			{Name: "transform", Type: ArgInt},
+		}, Event: WlOutputGeometryEvent{}},
+		{Name: "mode", Since: 1, Args: []Arg{
+			{Name: "flags", Type: ArgUint},
+			{Name: "width", Type: ArgInt},
+			{Name: "height", Type: ArgInt},
+			{Name: "refresh", Type: ArgInt},
+		}, Event: WlOutputModeEvent{}},
+		{Name: "done", Since: 2, Args: []Arg{}, Event: WlOutputDoneEvent{}},
+		{Name: "scale", Since: 2, Args: []Arg{
+			{Name: "factor", Type: ArgInt},
+		}, Event: WlOutputScaleEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "subpixel", Entries: []EnumEntry{
+			{Name: "unknown", Value: 0},
+			{Name: "none", Value: 1},
+			{Name: "horizontal_rgb", Value: 2},
+			{Name: "horizontal_bgr", Value: 3},
+			{Name: "vertical_rgb", Value: 4},
+			{Name: "vertical_bgr", Value: 5},
+		}},
+		{Name: "transform", Entries: []EnumEntry{
+			{Name: "normal", Value: 0},
+			{Name: "90", Value: 1},
+			{Name: "180", Value: 2},
+			{Name: "270", Value: 3},
+			{Name: "flipped", Value: 4},
+			{Name: "flipped_90", Value: 5},
+			{Name: "flipped_180", Value: 6},
+			{Name: "flipped_270", Value: 7},
+		}},
+		{Name: "mode", Entries: []EnumEntry{
+			{Name: "current", Value: 1},
+			{Name: "preferred", Value: 2},
+		}},
+	},
+}
+
+func init() { registerInterface(WlOutputInterface) }
@@ -75,6 +75,42 @@ type WlPointer interface {
 	// wl_pointer_destroy() after using this request.
 	Release()
 }
+
+// WlPointerEnterEvent holds the arguments of a wl_pointer.enter event.
+type WlPointerEnterEvent struct {
+	Serial   WlUint
+	Surface  WlObject
+	SurfaceX WlFixed
+	SurfaceY WlFixed
+}
+
+// WlPointerLeaveEvent holds the arguments of a wl_pointer.leave event.
+type WlPointerLeaveEvent struct {
+	Serial  WlUint
+	Surface WlObject
+}
+
+// WlPointerMotionEvent holds the arguments of a wl_pointer.motion event.
+type WlPointerMotionEvent struct {
+	Time     WlUint
+	SurfaceX WlFixed
+	SurfaceY WlFixed
+}
+
+// WlPointerButtonEvent holds the arguments of a wl_pointer.button event.
+type WlPointerButtonEvent struct {
+	Serial WlUint
+	Time   WlUint
+	Button WlUint
+	State  WlUint
+}
+
+// WlPointerAxisEvent holds the arguments of a wl_pointer.axis event.
+type WlPointerAxisEvent struct {
+	Time  WlUint
+	Axis  WlUint
+	Value WlFixed
+}
 type WlPointerError uint32
 
 const (
@@ -97,3 +133,60 @@ const (
 	WlPointerVerticalScroll   WlPointerAxis = 0
 	WlPointerHorizontalScroll WlPointerAxis = 1
 )
+
+var WlPointerInterface = &Interface{
+	Name:    "wl_pointer",
+	Version: 3,
+	Requests: []Message{
+		{Name: "set_cursor", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface", AllowNull: true},
+			{Name: "hotspot_x", Type: ArgInt},
+			{Name: "hotspot_y", Type: ArgInt},
+		}},
+		{Name: "release", Since: 3, Destructor: true, Args: []Arg{}},
+	},
+	Events: []Message{
+		{Name: "enter", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "surface_x", Type: ArgFixed},
+			{Name: "surface_y", Type: ArgFixed},
+		}, Event: WlPointerEnterEvent{}},
+		{Name: "leave", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+		}, Event: WlPointerLeaveEvent{}},
+		{Name: "motion", Since: 1, Args: []Arg{
+			{Name: "time", Type: ArgUint},
+			{Name: "surface_x", Type: ArgFixed},
+			{Name: "surface_y", Type: ArgFixed},
+		}, Event: WlPointerMotionEvent{}},
+		{Name: "button", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "time", Type: ArgUint},
+			{Name: "button", Type: ArgUint},
+			{Name: "state", Type: ArgUint},
+		}, Event: WlPointerButtonEvent{}},
+		{Name: "axis", Since: 1, Args: []Arg{
+			{Name: "time", Type: ArgUint},
+			{Name: "axis", Type: ArgUint},
+			{Name: "value", Type: ArgFixed},
+		}, Event: WlPointerAxisEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "role", Value: 0},
+		}},
+		{Name: "button_state", Entries: []EnumEntry{
+			{Name: "released", Value: 0},
+			{Name: "pressed", Value: 1},
+		}},
+		{Name: "axis", Entries: []EnumEntry{
+			{Name: "vertical_scroll", Value: 0},
+			{Name: "horizontal_scroll", Value: 1},
+		}},
+	},
+}
+
+func init() { registerInterface(WlPointerInterface) }
@@ -11,3 +11,27 @@ type WlRegion interface {
 	// Subtract the specified rectangle from the region.
 	Subtract(X WlInt, Y WlInt, Width WlInt, Height WlInt)
 }
+
+var WlRegionInterface = &Interface{
+	Name:    "wl_region",
+	Version: 1,
+	Requests: []Message{
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+		{Name: "add", Since: 1, Args: []Arg{
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+			{Name: "width", Type: ArgInt},
+			{Name: "height", Type: ArgInt},
+		}},
+		{Name: "subtract", Since: 1, Args: []Arg{
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+			{Name: "width", Type: ArgInt},
+			{Name: "height", Type: ArgInt},
+		}},
+	},
+	Events: []Message{},
+	Enums:  []Enum{},
+}
+
+func init() { registerInterface(WlRegionInterface) }
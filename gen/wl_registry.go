@@ -37,3 +37,39 @@ type WlRegistry interface {
 	// specified name as the identifier.
 	Bind(Name WlUint, Id WlNewId)
 }
+
+// WlRegistryGlobalEvent holds the arguments of a wl_registry.global event.
+type WlRegistryGlobalEvent struct {
+	Name        WlUint
+	WlInterface WlString
+	Version     WlUint
+}
+
+// WlRegistryGlobalRemoveEvent holds the arguments of a wl_registry.global_remove event.
+type WlRegistryGlobalRemoveEvent struct {
+	Name WlUint
+}
+
+var WlRegistryInterface = &Interface{
+	Name:    "wl_registry",
+	Version: 1,
+	Requests: []Message{
+		{Name: "bind", Since: 1, Args: []Arg{
+			{Name: "name", Type: ArgUint},
+			{Name: "id", Type: ArgNewId},
+		}},
+	},
+	Events: []Message{
+		{Name: "global", Since: 1, Args: []Arg{
+			{Name: "name", Type: ArgUint},
+			{Name: "interface", Type: ArgString},
+			{Name: "version", Type: ArgUint},
+		}, Event: WlRegistryGlobalEvent{}},
+		{Name: "global_remove", Since: 1, Args: []Arg{
+			{Name: "name", Type: ArgUint},
+		}, Event: WlRegistryGlobalRemoveEvent{}},
+	},
+	Enums: []Enum{},
+}
+
+func init() { registerInterface(WlRegistryInterface) }
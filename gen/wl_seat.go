@@ -30,6 +30,16 @@ type WlSeat interface {
 	GetTouch(Id WlNewId)
 }
 
+// WlSeatCapabilitiesEvent holds the arguments of a wl_seat.capabilities event.
+type WlSeatCapabilitiesEvent struct {
+	Capabilities WlUint
+}
+
+// WlSeatNameEvent holds the arguments of a wl_seat.name event.
+type WlSeatNameEvent struct {
+	Name WlString
+}
+
 // This is a bitmask of capabilities this seat has; if a member is
 // set, then it is present on the seat.
 type WlSeatCapability uint32
@@ -39,3 +49,36 @@ const (
 	WlSeatKeyboard WlSeatCapability = 2
 	WlSeatTouch    WlSeatCapability = 4
 )
+
+var WlSeatInterface = &Interface{
+	Name:    "wl_seat",
+	Version: 4,
+	Requests: []Message{
+		{Name: "get_pointer", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_pointer"},
+		}},
+		{Name: "get_keyboard", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_keyboard"},
+		}},
+		{Name: "get_touch", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_touch"},
+		}},
+	},
+	Events: []Message{
+		{Name: "capabilities", Since: 1, Args: []Arg{
+			{Name: "capabilities", Type: ArgUint},
+		}, Event: WlSeatCapabilitiesEvent{}},
+		{Name: "name", Since: 2, Args: []Arg{
+			{Name: "name", Type: ArgString},
+		}, Event: WlSeatNameEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "capability", Entries: []EnumEntry{
+			{Name: "pointer", Value: 1},
+			{Name: "keyboard", Value: 2},
+			{Name: "touch", Value: 4},
+		}},
+	},
+}
+
+func init() { registerInterface(WlSeatInterface) }
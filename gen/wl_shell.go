@@ -16,3 +16,22 @@ type WlShellError uint32
 const (
 	WlShellRole WlShellError = 0
 )
+
+var WlShellInterface = &Interface{
+	Name:    "wl_shell",
+	Version: 1,
+	Requests: []Message{
+		{Name: "get_shell_surface", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_shell_surface"},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+		}},
+	},
+	Events: []Message{},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "role", Value: 0},
+		}},
+	},
+}
+
+func init() { registerInterface(WlShellInterface) }
@@ -126,6 +126,22 @@ type WlShellSurface interface {
 	SetClass(Class WlString)
 }
 
+// WlShellSurfacePingEvent holds the arguments of a wl_shell_surface.ping event.
+type WlShellSurfacePingEvent struct {
+	Serial WlUint
+}
+
+// WlShellSurfaceConfigureEvent holds the arguments of a wl_shell_surface.configure event.
+type WlShellSurfaceConfigureEvent struct {
+	Edges  WlUint
+	Width  WlInt
+	Height WlInt
+}
+
+// WlShellSurfacePopupDoneEvent holds the arguments of a wl_shell_surface.popup_done event.
+type WlShellSurfacePopupDoneEvent struct {
+}
+
 // These values are used to indicate which edge of a surface
 // is being dragged in a resize operation. The server may
 // use this information to adapt its behavior, e.g. choose
@@ -163,3 +179,86 @@ const (
 	WlShellSurfaceDriver  WlShellSurfaceFullscreenMethod = 2
 	WlShellSurfaceFill    WlShellSurfaceFullscreenMethod = 3
 )
+
+var WlShellSurfaceInterface = &Interface{
+	Name:    "wl_shell_surface",
+	Version: 1,
+	Requests: []Message{
+		{Name: "pong", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+		}},
+		{Name: "move", Since: 1, Args: []Arg{
+			{Name: "seat", Type: ArgObject, Interface: "wl_seat"},
+			{Name: "serial", Type: ArgUint},
+		}},
+		{Name: "resize", Since: 1, Args: []Arg{
+			{Name: "seat", Type: ArgObject, Interface: "wl_seat"},
+			{Name: "serial", Type: ArgUint},
+			{Name: "edges", Type: ArgUint},
+		}},
+		{Name: "set_toplevel", Since: 1, Args: []Arg{}},
+		{Name: "set_transient", Since: 1, Args: []Arg{
+			{Name: "parent", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+			{Name: "flags", Type: ArgUint},
+		}},
+		{Name: "set_fullscreen", Since: 1, Args: []Arg{
+			{Name: "method", Type: ArgUint},
+			{Name: "framerate", Type: ArgUint},
+			{Name: "output", Type: ArgObject, Interface: "wl_output", AllowNull: true},
+		}},
+		{Name: "set_popup", Since: 1, Args: []Arg{
+			{Name: "seat", Type: ArgObject, Interface: "wl_seat"},
+			{Name: "serial", Type: ArgUint},
+			{Name: "parent", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+			{Name: "flags", Type: ArgUint},
+		}},
+		{Name: "set_maximized", Since: 1, Args: []Arg{
+			{Name: "output", Type: ArgObject, Interface: "wl_output", AllowNull: true},
+		}},
+		{Name: "set_title", Since: 1, Args: []Arg{
+			{Name: "title", Type: ArgString},
+		}},
+		{Name: "set_class", Since: 1, Args: []Arg{
+			{Name: "class_", Type: ArgString},
+		}},
+	},
+	Events: []Message{
+		{Name: "ping", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+		}, Event: WlShellSurfacePingEvent{}},
+		{Name: "configure", Since: 1, Args: []Arg{
+			{Name: "edges", Type: ArgUint},
+			{Name: "width", Type: ArgInt},
+			{Name: "height", Type: ArgInt},
+		}, Event: WlShellSurfaceConfigureEvent{}},
+		{Name: "popup_done", Since: 1, Args: []Arg{}, Event: WlShellSurfacePopupDoneEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "resize", Entries: []EnumEntry{
+			{Name: "none", Value: 0},
+			{Name: "top", Value: 1},
+			{Name: "bottom", Value: 2},
+			{Name: "left", Value: 4},
+			{Name: "top_left", Value: 5},
+			{Name: "bottom_left", Value: 6},
+			{Name: "right", Value: 8},
+			{Name: "top_right", Value: 9},
+			{Name: "bottom_right", Value: 10},
+		}},
+		{Name: "transient", Entries: []EnumEntry{
+			{Name: "inactive", Value: 1},
+		}},
+		{Name: "fullscreen_method", Entries: []EnumEntry{
+			{Name: "default", Value: 0},
+			{Name: "scale", Value: 1},
+			{Name: "driver", Value: 2},
+			{Name: "fill", Value: 3},
+		}},
+	},
+}
+
+func init() { registerInterface(WlShellSurfaceInterface) }
@@ -19,6 +19,11 @@ type WlShm interface {
 	CreatePool(Id WlNewId, Fd WlFd, Size WlInt)
 }
 
+// WlShmFormatEvent holds the arguments of a wl_shm.format event.
+type WlShmFormatEvent struct {
+	Format WlUint
+}
+
 // These errors can be emitted in response to wl_shm requests.
 type WlShmError uint32
 
@@ -94,3 +99,89 @@ const (
 	WlShmYuv444      WlShmFormat = 0x34325559
 	WlShmYvu444      WlShmFormat = 0x34325659
 )
+
+var WlShmInterface = &Interface{
+	Name:    "wl_shm",
+	Version: 1,
+	Requests: []Message{
+		{Name: "create_pool", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_shm_pool"},
+			{Name: "fd", Type: ArgFd},
+			{Name: "size", Type: ArgInt},
+		}},
+	},
+	Events: []Message{
+		{Name: "format", Since: 1, Args: []Arg{
+			{Name: "format", Type: ArgUint},
+		}, Event: WlShmFormatEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "invalid_format", Value: 0},
+			{Name: "invalid_stride", Value: 1},
+			{Name: "invalid_fd", Value: 2},
+		}},
+		{Name: "format", Entries: []EnumEntry{
+			{Name: "argb8888", Value: 0},
+			{Name: "xrgb8888", Value: 1},
+			{Name: "c8", Value: 538982467},
+			{Name: "rgb332", Value: 943867730},
+			{Name: "bgr233", Value: 944916290},
+			{Name: "xrgb4444", Value: 842093144},
+			{Name: "xbgr4444", Value: 842089048},
+			{Name: "rgbx4444", Value: 842094674},
+			{Name: "bgrx4444", Value: 842094658},
+			{Name: "argb4444", Value: 842093121},
+			{Name: "abgr4444", Value: 842089025},
+			{Name: "rgba4444", Value: 842088786},
+			{Name: "bgra4444", Value: 842088770},
+			{Name: "xrgb1555", Value: 892424792},
+			{Name: "xbgr1555", Value: 892420696},
+			{Name: "rgbx5551", Value: 892426322},
+			{Name: "bgrx5551", Value: 892426306},
+			{Name: "argb1555", Value: 892424769},
+			{Name: "abgr1555", Value: 892420673},
+			{Name: "rgba5551", Value: 892420434},
+			{Name: "bgra5551", Value: 892420418},
+			{Name: "rgb565", Value: 909199186},
+			{Name: "bgr565", Value: 909199170},
+			{Name: "rgb888", Value: 875710290},
+			{Name: "bgr888", Value: 875710274},
+			{Name: "xbgr8888", Value: 875709016},
+			{Name: "rgbx8888", Value: 875714642},
+			{Name: "bgrx8888", Value: 875714626},
+			{Name: "abgr8888", Value: 875708993},
+			{Name: "rgba8888", Value: 875708754},
+			{Name: "bgra8888", Value: 875708738},
+			{Name: "xrgb2101010", Value: 808669784},
+			{Name: "xbgr2101010", Value: 808665688},
+			{Name: "rgbx1010102", Value: 808671314},
+			{Name: "bgrx1010102", Value: 808671298},
+			{Name: "argb2101010", Value: 808669761},
+			{Name: "abgr2101010", Value: 808665665},
+			{Name: "rgba1010102", Value: 808665426},
+			{Name: "bgra1010102", Value: 808665410},
+			{Name: "yuyv", Value: 1448695129},
+			{Name: "yvyu", Value: 1431918169},
+			{Name: "uyvy", Value: 1498831189},
+			{Name: "vyuy", Value: 1498765654},
+			{Name: "ayuv", Value: 1448433985},
+			{Name: "nv12", Value: 842094158},
+			{Name: "nv21", Value: 825382478},
+			{Name: "nv16", Value: 909203022},
+			{Name: "nv61", Value: 825644622},
+			{Name: "yuv410", Value: 961959257},
+			{Name: "yvu410", Value: 961893977},
+			{Name: "yuv411", Value: 825316697},
+			{Name: "yvu411", Value: 825316953},
+			{Name: "yuv420", Value: 842093913},
+			{Name: "yvu420", Value: 842094169},
+			{Name: "yuv422", Value: 909202777},
+			{Name: "yvu422", Value: 909203033},
+			{Name: "yuv444", Value: 875713881},
+			{Name: "yvu444", Value: 875714137},
+		}},
+	},
+}
+
+func init() { registerInterface(WlShmInterface) }
@@ -29,3 +29,26 @@ type WlShmPool interface {
 	// used to make the pool bigger.
 	Resize(Size WlInt)
 }
+
+var WlShmPoolInterface = &Interface{
+	Name:    "wl_shm_pool",
+	Version: 1,
+	Requests: []Message{
+		{Name: "create_buffer", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_buffer"},
+			{Name: "offset", Type: ArgInt},
+			{Name: "width", Type: ArgInt},
+			{Name: "height", Type: ArgInt},
+			{Name: "stride", Type: ArgInt},
+			{Name: "format", Type: ArgUint},
+		}},
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+		{Name: "resize", Since: 1, Args: []Arg{
+			{Name: "size", Type: ArgInt},
+		}},
+	},
+	Events: []Message{},
+	Enums:  []Enum{},
+}
+
+func init() { registerInterface(WlShmPoolInterface) }
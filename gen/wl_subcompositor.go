@@ -34,3 +34,24 @@ type WlSubcompositorError uint32
 const (
 	WlSubcompositorBadSurface WlSubcompositorError = 0
 )
+
+var WlSubcompositorInterface = &Interface{
+	Name:    "wl_subcompositor",
+	Version: 1,
+	Requests: []Message{
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+		{Name: "get_subsurface", Since: 1, Args: []Arg{
+			{Name: "id", Type: ArgNewId, Interface: "wl_subsurface"},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "parent", Type: ArgObject, Interface: "wl_surface"},
+		}},
+	},
+	Events: []Message{},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "bad_surface", Value: 0},
+		}},
+	},
+}
+
+func init() { registerInterface(WlSubcompositorInterface) }
@@ -114,3 +114,31 @@ type WlSubsurfaceError uint32
 const (
 	WlSubsurfaceBadSurface WlSubsurfaceError = 0
 )
+
+var WlSubsurfaceInterface = &Interface{
+	Name:    "wl_subsurface",
+	Version: 1,
+	Requests: []Message{
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+		{Name: "set_position", Since: 1, Args: []Arg{
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+		}},
+		{Name: "place_above", Since: 1, Args: []Arg{
+			{Name: "sibling", Type: ArgObject, Interface: "wl_surface"},
+		}},
+		{Name: "place_below", Since: 1, Args: []Arg{
+			{Name: "sibling", Type: ArgObject, Interface: "wl_surface"},
+		}},
+		{Name: "set_sync", Since: 1, Args: []Arg{}},
+		{Name: "set_desync", Since: 1, Args: []Arg{}},
+	},
+	Events: []Message{},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "bad_surface", Value: 0},
+		}},
+	},
+}
+
+func init() { registerInterface(WlSubsurfaceInterface) }
@@ -217,6 +217,16 @@ type WlSurface interface {
 	SetBufferScale(Scale WlInt)
 }
 
+// WlSurfaceEnterEvent holds the arguments of a wl_surface.enter event.
+type WlSurfaceEnterEvent struct {
+	Output WlObject
+}
+
+// WlSurfaceLeaveEvent holds the arguments of a wl_surface.leave event.
+type WlSurfaceLeaveEvent struct {
+	Output WlObject
+}
+
 // These errors can be emitted in response to wl_surface requests.
 type WlSurfaceError uint32
 
@@ -224,3 +234,54 @@ const (
 	WlSurfaceInvalidScale     WlSurfaceError = 0
 	WlSurfaceInvalidTransform WlSurfaceError = 1
 )
+
+var WlSurfaceInterface = &Interface{
+	Name:    "wl_surface",
+	Version: 3,
+	Requests: []Message{
+		{Name: "destroy", Since: 1, Destructor: true, Args: []Arg{}},
+		{Name: "attach", Since: 1, Args: []Arg{
+			{Name: "buffer", Type: ArgObject, Interface: "wl_buffer", AllowNull: true},
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+		}},
+		{Name: "damage", Since: 1, Args: []Arg{
+			{Name: "x", Type: ArgInt},
+			{Name: "y", Type: ArgInt},
+			{Name: "width", Type: ArgInt},
+			{Name: "height", Type: ArgInt},
+		}},
+		{Name: "frame", Since: 1, Args: []Arg{
+			{Name: "callback", Type: ArgNewId, Interface: "wl_callback"},
+		}},
+		{Name: "set_opaque_region", Since: 1, Args: []Arg{
+			{Name: "region", Type: ArgObject, Interface: "wl_region", AllowNull: true},
+		}},
+		{Name: "set_input_region", Since: 1, Args: []Arg{
+			{Name: "region", Type: ArgObject, Interface: "wl_region", AllowNull: true},
+		}},
+		{Name: "commit", Since: 1, Args: []Arg{}},
+		{Name: "set_buffer_transform", Since: 2, Args: []Arg{
+			{Name: "transform", Type: ArgInt},
+		}},
+		{Name: "set_buffer_scale", Since: 3, Args: []Arg{
+			{Name: "scale", Type: ArgInt},
+		}},
+	},
+	Events: []Message{
+		{Name: "enter", Since: 1, Args: []Arg{
+			{Name: "output", Type: ArgObject, Interface: "wl_output"},
+		}, Event: WlSurfaceEnterEvent{}},
+		{Name: "leave", Since: 1, Args: []Arg{
+			{Name: "output", Type: ArgObject, Interface: "wl_output"},
+		}, Event: WlSurfaceLeaveEvent{}},
+	},
+	Enums: []Enum{
+		{Name: "error", Entries: []EnumEntry{
+			{Name: "invalid_scale", Value: 0},
+			{Name: "invalid_transform", Value: 1},
+		}},
+	},
+}
+
+func init() { registerInterface(WlSurfaceInterface) }
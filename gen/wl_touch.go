@@ -30,3 +30,70 @@ type WlTouch interface {
 	Cancel()
 	Release()
 }
+
+// WlTouchDownEvent holds the arguments of a wl_touch.down event.
+type WlTouchDownEvent struct {
+	Serial  WlUint
+	Time    WlUint
+	Surface WlObject
+	Id      WlInt
+	X       WlFixed
+	Y       WlFixed
+}
+
+// WlTouchUpEvent holds the arguments of a wl_touch.up event.
+type WlTouchUpEvent struct {
+	Serial WlUint
+	Time   WlUint
+	Id     WlInt
+}
+
+// WlTouchMotionEvent holds the arguments of a wl_touch.motion event.
+type WlTouchMotionEvent struct {
+	Time WlUint
+	Id   WlInt
+	X    WlFixed
+	Y    WlFixed
+}
+
+// WlTouchFrameEvent holds the arguments of a wl_touch.frame event.
+type WlTouchFrameEvent struct {
+}
+
+// WlTouchCancelEvent holds the arguments of a wl_touch.cancel event.
+type WlTouchCancelEvent struct {
+}
+
+var WlTouchInterface = &Interface{
+	Name:    "wl_touch",
+	Version: 3,
+	Requests: []Message{
+		{Name: "release", Since: 3, Destructor: true, Args: []Arg{}},
+	},
+	Events: []Message{
+		{Name: "down", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "time", Type: ArgUint},
+			{Name: "surface", Type: ArgObject, Interface: "wl_surface"},
+			{Name: "id", Type: ArgInt},
+			{Name: "x", Type: ArgFixed},
+			{Name: "y", Type: ArgFixed},
+		}, Event: WlTouchDownEvent{}},
+		{Name: "up", Since: 1, Args: []Arg{
+			{Name: "serial", Type: ArgUint},
+			{Name: "time", Type: ArgUint},
+			{Name: "id", Type: ArgInt},
+		}, Event: WlTouchUpEvent{}},
+		{Name: "motion", Since: 1, Args: []Arg{
+			{Name: "time", Type: ArgUint},
+			{Name: "id", Type: ArgInt},
+			{Name: "x", Type: ArgFixed},
+			{Name: "y", Type: ArgFixed},
+		}, Event: WlTouchMotionEvent{}},
+		{Name: "frame", Since: 1, Args: []Arg{}, Event: WlTouchFrameEvent{}},
+		{Name: "cancel", Since: 1, Args: []Arg{}, Event: WlTouchCancelEvent{}},
+	},
+	Enums: []Enum{},
+}
+
+func init() { registerInterface(WlTouchInterface) }
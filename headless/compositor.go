@@ -0,0 +1,210 @@
+// Package headless is a compositor that needs no GPU, display or seat. It
+// implements the core protocol on top of the server runtime and composites
+// client surfaces into an in-memory framebuffer that tests can read back.
+package headless
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/server"
+)
+
+// Options configure a headless compositor.
+type Options struct {
+	// Width and Height are the size of the output and framebuffer,
+	// 1024x768 if zero.
+	Width, Height int
+	// Background fills the framebuffer where no surface is drawn. The
+	// default is opaque black.
+	Background color.Color
+}
+
+// A Compositor serves wl_compositor, wl_shm, wl_subcompositor, wl_shell,
+// wl_seat, wl_data_device_manager and wl_output on a server Display. Like
+// the Display, it may only be used from the goroutine dispatching the
+// display's event loop.
+//
+// Positions are in a global space in which the framebuffer covers the
+// Options' size at the origin, as does the output New creates. Outputs
+// added with AddOutput extend the space without growing the framebuffer.
+type Compositor struct {
+	display    *server.Display
+	shm        *server.Shm
+	outputs    []*server.Output
+	seat       *Seat
+	fb         *image.RGBA
+	background image.Image
+	start      time.Time
+
+	// toplevels are the mapped shell surfaces, bottom to top.
+	toplevels []*Surface
+	// frames are the surfaces with committed frame callbacks waiting for
+	// a repaint.
+	frames        []*server.Surface
+	repaintQueued bool
+}
+
+// New creates a headless compositor serving d.
+func New(d *server.Display, opts Options) (*Compositor, error) {
+	if opts.Width == 0 || opts.Height == 0 {
+		opts.Width, opts.Height = 1024, 768
+	}
+	if opts.Background == nil {
+		opts.Background = color.Black
+	}
+	c := &Compositor{
+		display:    d,
+		fb:         image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height)),
+		background: image.NewUniform(opts.Background),
+		start:      time.Now(),
+	}
+	draw.Draw(c.fb, c.fb.Rect, c.background, image.Point{}, draw.Src)
+
+	var err error
+	if c.shm, err = d.InitShm(); err != nil {
+		return nil, err
+	}
+	if _, err = d.AddGlobal(gen.WlCompositorInterface, 3, c.bindCompositor); err != nil {
+		return nil, err
+	}
+	if _, err = d.AddGlobal(gen.WlSubcompositorInterface, 1, c.bindSubcompositor); err != nil {
+		return nil, err
+	}
+	if _, err = d.AddGlobal(gen.WlShellInterface, 1, c.bindShell); err != nil {
+		return nil, err
+	}
+	if _, err = c.AddOutput(server.OutputInfo{
+		Make:  "goland",
+		Model: "headless",
+		Modes: []server.OutputMode{{Width: opts.Width, Height: opts.Height, Refresh: 60000, Preferred: true}},
+	}); err != nil {
+		return nil, err
+	}
+	if c.seat, err = newSeat(c, "seat0"); err != nil {
+		return nil, err
+	}
+	if _, err = d.AddGlobal(gen.WlDataDeviceManagerInterface, 2, c.bindDataDeviceManager); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Display returns the display the compositor serves.
+func (c *Compositor) Display() *server.Display {
+	return c.display
+}
+
+// Shm returns the compositor's wl_shm.
+func (c *Compositor) Shm() *server.Shm {
+	return c.shm
+}
+
+// Seat returns the compositor's seat.
+func (c *Compositor) Seat() *Seat {
+	return c.seat
+}
+
+// Toplevels returns the mapped shell surfaces, bottom to top.
+func (c *Compositor) Toplevels() []*Surface {
+	return append([]*Surface(nil), c.toplevels...)
+}
+
+// now returns the time for event timestamps and frame callbacks, in
+// milliseconds since the compositor started.
+func (c *Compositor) now() uint32 {
+	return uint32(time.Since(c.start) / time.Millisecond)
+}
+
+// scheduleRepaint arranges for a repaint once the current events have
+// been handled.
+func (c *Compositor) scheduleRepaint() {
+	if c.repaintQueued {
+		return
+	}
+	c.repaintQueued = true
+	c.display.EventLoop().AddIdle(c.Repaint)
+}
+
+// Repaint composites the mapped surfaces into the framebuffer and completes
+// the pending frame callbacks. It runs by itself after surfaces change.
+func (c *Compositor) Repaint() {
+	c.repaintQueued = false
+	draw.Draw(c.fb, c.fb.Rect, c.background, image.Point{}, draw.Src)
+	for _, s := range c.toplevels {
+		x, y := s.Position()
+		s.drawTree(c.fb, x, y)
+	}
+	if c.seat.drag != nil {
+		c.seat.drag.drawIcon(c.fb)
+	}
+	c.updateOutputs()
+
+	now := c.now()
+	frames := c.frames
+	c.frames = nil
+	for _, s := range frames {
+		s.SendFrameDone(now)
+	}
+}
+
+// Snapshot returns a copy of the framebuffer as of the last repaint,
+// repainting first if one is due.
+func (c *Compositor) Snapshot() *image.RGBA {
+	if c.repaintQueued {
+		c.Repaint()
+	}
+	img := image.NewRGBA(c.fb.Rect)
+	copy(img.Pix, c.fb.Pix)
+	return img
+}
+
+func (c *Compositor) mapSurface(s *Surface) {
+	for _, v := range c.toplevels {
+		if v == s {
+			return
+		}
+	}
+	c.toplevels = append(c.toplevels, s)
+	c.scheduleRepaint()
+}
+
+func (c *Compositor) unmapSurface(s *Surface) {
+	for i, v := range c.toplevels {
+		if v == s {
+			c.seat.surfaceGone(s)
+			c.toplevels = append(c.toplevels[:i], c.toplevels[i+1:]...)
+			c.scheduleRepaint()
+			return
+		}
+	}
+}
+
+func (c *Compositor) bindCompositor(r *server.Resource) {
+	r.SetImplementation(compositorImpl{c, r})
+}
+
+// compositorImpl implements wl_compositor.
+type compositorImpl struct {
+	c   *Compositor
+	res *server.Resource
+}
+
+func (ci compositorImpl) CreateSurface(Id gen.WlNewId) {
+	r, err := ci.res.Client().NewResource(uint32(Id), gen.WlSurfaceInterface, ci.res.Version())
+	if err != nil {
+		return
+	}
+	newSurface(ci.c, r)
+}
+
+func (ci compositorImpl) CreateRegion(Id gen.WlNewId) {
+	r, err := ci.res.Client().NewResource(uint32(Id), gen.WlRegionInterface, ci.res.Version())
+	if err != nil {
+		return
+	}
+	server.NewRegion(r)
+}
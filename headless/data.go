@@ -0,0 +1,349 @@
+package headless
+
+import (
+	"errors"
+	"image"
+	"os"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/server"
+	"golang.org/x/sys/unix"
+)
+
+// RoleDragIcon is the role of a surface used as the icon of a drag.
+const RoleDragIcon = "wl_data_device-icon"
+
+// dataState is the selection and drag state of a seat.
+type dataState struct {
+	dataDevices []*server.Resource
+	selection   *DataSource
+	// selectionSerial is the serial the client that set the selection
+	// gave with it.
+	selectionSerial uint32
+	drag            *drag
+}
+
+// A DataSource is a client's wl_data_source: data it can send in some MIME
+// types, as the selection or for a drag.
+type DataSource struct {
+	res       *server.Resource
+	mimeTypes []string
+	// accepted is whether the target of a drag accepted one of the types.
+	accepted bool
+}
+
+// Resource returns the source's wl_data_source resource.
+func (src *DataSource) Resource() *server.Resource {
+	return src.res
+}
+
+// MimeTypes returns the types the source has offered.
+func (src *DataSource) MimeTypes() []string {
+	return append([]string(nil), src.mimeTypes...)
+}
+
+func sourceFromResource(r *server.Resource) *DataSource {
+	if r == nil {
+		return nil
+	}
+	src, _ := r.UserData().(*DataSource)
+	return src
+}
+
+// dataSourceImpl implements wl_data_source.
+type dataSourceImpl struct {
+	src *DataSource
+}
+
+func (si dataSourceImpl) Offer(MimeType gen.WlString) {
+	si.src.mimeTypes = append(si.src.mimeTypes, string(MimeType))
+}
+
+func (c *Compositor) bindDataDeviceManager(r *server.Resource) {
+	r.SetImplementation(dataDeviceManagerImpl{c, r})
+}
+
+// dataDeviceManagerImpl implements wl_data_device_manager.
+type dataDeviceManagerImpl struct {
+	c   *Compositor
+	res *server.Resource
+}
+
+func (mi dataDeviceManagerImpl) CreateDataSource(Id gen.WlNewId) {
+	r, err := mi.res.Client().NewResource(uint32(Id), gen.WlDataSourceInterface, mi.res.Version())
+	if err != nil {
+		return
+	}
+	src := &DataSource{res: r}
+	r.SetImplementation(dataSourceImpl{src})
+	r.SetUserData(src)
+	seat := mi.c.seat
+	r.AddDestroyListener(func(*server.Resource) { seat.sourceDestroyed(src) })
+}
+
+func (mi dataDeviceManagerImpl) GetDataDevice(Id gen.WlNewId, Seat gen.WlObject) {
+	r, err := mi.res.Client().NewResource(uint32(Id), gen.WlDataDeviceInterface, mi.res.Version())
+	if err != nil {
+		return
+	}
+	// Every wl_seat is the compositor's one seat.
+	seat := mi.c.seat
+	r.SetImplementation(dataDeviceImpl{seat, r})
+	track(&seat.dataDevices, r)
+	if f := seat.keyboardFocus; f != nil && f.Resource().Client() == r.Client() {
+		seat.sendSelection(r)
+	}
+}
+
+// dataDeviceImpl implements wl_data_device.
+type dataDeviceImpl struct {
+	s   *Seat
+	res *server.Resource
+}
+
+func (di dataDeviceImpl) SetSelection(Source gen.WlObject, Serial gen.WlUint) {
+	s := di.s
+	client := di.res.Client()
+	// The serial must be of a recent input event sent to the client, and
+	// no older than the one the current selection was set with.
+	rec, ok := s.serials.Lookup(uint32(Serial))
+	if !ok || rec.Surface == nil || rec.Surface.Resource().Client() != client ||
+		(s.selection != nil && int32(rec.Serial-s.selectionSerial) < 0) {
+		return
+	}
+	s.selectionSerial = rec.Serial
+	s.SetSelection(sourceFromResource(client.Resource(uint32(Source))))
+}
+
+func (di dataDeviceImpl) StartDrag(Source gen.WlObject, Origin gen.WlObject, Icon gen.WlObject, Serial gen.WlUint) {
+	s := di.s
+	client := di.res.Client()
+	// A drag starts from a button press on the origin that is still held.
+	rec, ok := s.serials.ImplicitGrab(uint32(Serial), client)
+	origin := SurfaceFromResource(client.Resource(uint32(Origin)))
+	if !ok || rec.Kind != server.SerialPointerButton || origin == nil || rec.Surface != origin.core || s.drag != nil || s.grab != nil {
+		return
+	}
+	d := &drag{seat: s, source: sourceFromResource(client.Resource(uint32(Source))), client: client}
+	if Icon != 0 {
+		d.icon = SurfaceFromResource(client.Resource(uint32(Icon)))
+		if !d.icon.core.SetRole(RoleDragIcon, di.res, uint32(gen.WlDataDeviceRole)) {
+			return
+		}
+		d.icon.x, d.icon.y = 0, 0
+	}
+	// The drag takes the pointer: the origin gets wl_pointer.leave, and
+	// pointer motion is reported through the data devices until the
+	// button is released.
+	s.setPointerFocus(nil, 0, 0)
+	s.drag = d
+	d.motion(s.px, s.py)
+}
+
+// Selection returns the data source of the seat's selection, or nil.
+func (s *Seat) Selection() *DataSource {
+	return s.selection
+}
+
+// SetSelection makes src the seat's selection, or clears the selection if
+// src is nil. The previous source is cancelled, and the client with the
+// keyboard focus is offered the new selection.
+func (s *Seat) SetSelection(src *DataSource) {
+	if src == s.selection {
+		return
+	}
+	if old := s.selection; old != nil {
+		old.res.PostEvent(2)
+	}
+	s.selection = src
+	for _, dev := range resourcesOf(s.dataDevices, s.keyboardFocus) {
+		s.sendSelection(dev)
+	}
+}
+
+// ReadSelection asks the selection's source for its data in the given MIME
+// type and returns the read end of the pipe the client writes it to. The
+// client writes once the display dispatches, so the pipe must be read on
+// another goroutine.
+func (s *Seat) ReadSelection(mimeType string) (*os.File, error) {
+	src := s.selection
+	if src == nil {
+		return nil, errors.New("ReadSelection: no selection")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	if err := src.res.PostEvent(1, gen.WlString(mimeType), gen.WlFd(w.Fd())); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// sendSelection tells a data device about the selection, with a new offer
+// for it.
+func (s *Seat) sendSelection(dev *server.Resource) {
+	var id uint32
+	if s.selection != nil {
+		if offer := newOffer(dev, s.selection, false); offer != nil {
+			id = offer.Id()
+		}
+	}
+	dev.PostEvent(5, gen.WlObject(id))
+}
+
+// sourceDestroyed drops a source from the selection or the drag using it.
+func (s *Seat) sourceDestroyed(src *DataSource) {
+	if s.selection == src {
+		s.selection = nil
+		for _, dev := range resourcesOf(s.dataDevices, s.keyboardFocus) {
+			s.sendSelection(dev)
+		}
+	}
+	if s.drag != nil && s.drag.source == src {
+		s.drag.source = nil
+		s.drag.end()
+	}
+}
+
+// newOffer creates a wl_data_offer for src and announces it and its MIME
+// types on a data device.
+func newOffer(dev *server.Resource, src *DataSource, dnd bool) *server.Resource {
+	r, err := dev.Client().NewResource(0, gen.WlDataOfferInterface, dev.Version())
+	if err != nil {
+		return nil
+	}
+	r.SetImplementation(dataOfferImpl{src, dnd})
+	dev.PostEvent(0, gen.WlNewId(r.Id()))
+	for _, t := range src.mimeTypes {
+		r.PostEvent(0, gen.WlString(t))
+	}
+	return r
+}
+
+// dataOfferImpl implements wl_data_offer.
+type dataOfferImpl struct {
+	src *DataSource
+	dnd bool
+}
+
+func (oi dataOfferImpl) Accept(Serial gen.WlUint, MimeType gen.WlString) {
+	src := oi.src
+	if !oi.dnd || src.res.Destroyed() {
+		return
+	}
+	src.accepted = MimeType != ""
+	src.res.PostEvent(0, MimeType)
+}
+
+func (oi dataOfferImpl) Receive(MimeType gen.WlString, Fd gen.WlFd) {
+	if !oi.src.res.Destroyed() {
+		oi.src.res.PostEvent(1, MimeType, Fd)
+	}
+	unix.Close(int(Fd))
+}
+
+// Dragging reports whether a drag-and-drop session is in progress.
+func (s *Seat) Dragging() bool {
+	return s.drag != nil
+}
+
+// A drag is a drag-and-drop session driven by the pointer. Touch drags are
+// not supported.
+type drag struct {
+	seat *Seat
+	// source is nil for a drag within the client, which no other client
+	// sees.
+	source *DataSource
+	client *server.Client
+	icon   *Surface
+	focus  *Surface
+}
+
+// motion moves the drag to x, y on the output.
+func (d *drag) motion(x, y float64) {
+	s := d.seat
+	if d.icon != nil {
+		s.comp.scheduleRepaint()
+	}
+	surface, sx, sy := s.comp.surfaceAt(x, y)
+	if surface != nil && d.source == nil && surface.Resource().Client() != d.client {
+		surface = nil
+	}
+	if surface != d.focus {
+		d.setFocus(surface, sx, sy)
+		return
+	}
+	for _, dev := range resourcesOf(s.dataDevices, d.focus) {
+		dev.PostEvent(3, s.time(), gen.WlFixed(sx), gen.WlFixed(sy))
+	}
+}
+
+// setFocus moves the drag to another surface, with leave and enter events
+// and new offers.
+func (d *drag) setFocus(surface *Surface, sx, sy float64) {
+	s := d.seat
+	for _, dev := range resourcesOf(s.dataDevices, d.focus) {
+		dev.PostEvent(2)
+	}
+	if d.source != nil {
+		d.source.accepted = false
+	}
+	d.focus = surface
+	if surface == nil {
+		return
+	}
+	serial := s.serial(server.SerialDragEnter, surface, 0, false)
+	for _, dev := range resourcesOf(s.dataDevices, surface) {
+		var id uint32
+		if d.source != nil {
+			if offer := newOffer(dev, d.source, true); offer != nil {
+				id = offer.Id()
+			}
+		}
+		dev.PostEvent(1, serial, gen.WlObject(surface.Resource().Id()), gen.WlFixed(sx), gen.WlFixed(sy), gen.WlObject(id))
+	}
+}
+
+// drop ends the drag when the button is released: the surface under it gets
+// the drop if it accepted the data, or the source is cancelled.
+func (d *drag) drop() {
+	if d.focus != nil && (d.source == nil || d.source.accepted) {
+		for _, dev := range resourcesOf(d.seat.dataDevices, d.focus) {
+			dev.PostEvent(4)
+		}
+	} else if d.source != nil {
+		d.source.res.PostEvent(2)
+	}
+	d.end()
+}
+
+// end finishes the drag session.
+func (d *drag) end() {
+	d.setFocus(nil, 0, 0)
+	d.seat.drag = nil
+	if d.icon != nil {
+		d.seat.comp.scheduleRepaint()
+	}
+}
+
+// surfaceGone drops a surface that is unmapped or destroyed from the drag.
+func (d *drag) surfaceGone(surface *Surface) {
+	if d.focus != nil && d.focus.isDescendantOf(surface) {
+		d.setFocus(nil, 0, 0)
+	}
+	if d.icon == surface {
+		d.icon = nil
+	}
+}
+
+// drawIcon draws the drag icon at the pointer.
+func (d *drag) drawIcon(dst *image.RGBA) {
+	if d.icon == nil {
+		return
+	}
+	x, y := d.seat.PointerPosition()
+	d.icon.drawTree(dst, int(x)+d.icon.x, int(y)+d.icon.y)
+}
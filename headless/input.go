@@ -0,0 +1,481 @@
+package headless
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/server"
+)
+
+// Pointer buttons, as Linux evdev codes.
+const (
+	ButtonLeft   = 0x110
+	ButtonRight  = 0x111
+	ButtonMiddle = 0x112
+)
+
+// Modifier masks for SetModifiers and TypeKey. The seat sends no keymap, so
+// these are the masks of the standard xkb modifiers that clients assume.
+const (
+	ModShift = 1 << 0
+	ModCaps  = 1 << 1
+	ModCtrl  = 1 << 2
+	ModAlt   = 1 << 3
+	ModNum   = 1 << 4
+	ModLogo  = 1 << 6
+)
+
+// inputState is the state of a seat's injected input.
+type inputState struct {
+	px, py       float64
+	pointerFocus *Surface
+	buttons      []uint32
+	grab         *shellGrab
+	// sx and sy are the position last sent to the pointer focus.
+	sx, sy float64
+	// cursor is the cursor surface set by the client with the pointer
+	// focus, nil if it hid the cursor, and hotX and hotY its hotspot.
+	// cursorSet is false until the client sets one.
+	cursor     *Surface
+	hotX, hotY int
+	cursorSet  bool
+
+	keyboardFocus *Surface
+	keys          []uint32
+	mods          [4]uint32
+
+	points map[int32]*touchPoint
+}
+
+// A touchPoint is a touch that stays with the surface it went down on.
+type touchPoint struct {
+	surface *Surface
+	// ox and oy are the surface's position on the output at touch down.
+	ox, oy int
+}
+
+// resourcesOf returns the resources in list that belong to the client of
+// surface s.
+func resourcesOf(list []*server.Resource, s *Surface) []*server.Resource {
+	var out []*server.Resource
+	if s == nil {
+		return nil
+	}
+	c := s.Resource().Client()
+	for _, r := range list {
+		if r.Client() == c {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// serial takes the serial of an event for surface from the seat's
+// tracker.
+func (s *Seat) serial(kind server.SerialKind, surface *Surface, button uint32, pressed bool) gen.WlUint {
+	var core *server.Surface
+	if surface != nil {
+		core = surface.core
+	}
+	return gen.WlUint(s.serials.Next(kind, core, button, pressed))
+}
+
+func (s *Seat) time() gen.WlUint {
+	return gen.WlUint(s.comp.now())
+}
+
+// PointerPosition returns where the pointer is on the output.
+func (s *Seat) PointerPosition() (x, y float64) {
+	return s.px, s.py
+}
+
+// PointerFocus returns the surface the pointer is over, or nil.
+func (s *Seat) PointerFocus() *Surface {
+	return s.pointerFocus
+}
+
+// Cursor returns the cursor set by the client with the pointer focus: its
+// surface, or nil if the client hid the cursor, and its hotspot in surface
+// coordinates. set is false if the client has not set a cursor since the
+// pointer entered its surface, in which case the compositor's own cursor
+// would show.
+func (s *Seat) Cursor() (surface *Surface, hotspotX, hotspotY int, set bool) {
+	return s.cursor, s.hotX, s.hotY, s.cursorSet
+}
+
+// setCursor records the cursor set by the client with the pointer focus.
+func (s *Seat) setCursor(surface *Surface, hotX, hotY int) {
+	s.cursor, s.hotX, s.hotY, s.cursorSet = surface, hotX, hotY, true
+}
+
+// MovePointer moves the pointer to x, y on the output. The surface under
+// it, if it changes, gets wl_pointer.leave and the new one enter;
+// otherwise the surface gets motion. While a button is held, the surface it
+// was pressed on keeps the pointer, as with a real compositor's implicit
+// grab.
+func (s *Seat) MovePointer(x, y float64) {
+	s.px, s.py = x, y
+	if s.drag != nil {
+		s.drag.motion(x, y)
+		return
+	}
+	if s.grab != nil {
+		s.grab.motion(x, y)
+		return
+	}
+	if len(s.buttons) > 0 && s.pointerFocus != nil {
+		sx, sy := s.pointerFocus.local(x, y)
+		s.pointerMotion(sx, sy)
+		return
+	}
+	surface, sx, sy := s.comp.surfaceAt(x, y)
+	if surface != s.pointerFocus {
+		s.setPointerFocus(surface, sx, sy)
+		return
+	}
+	s.pointerMotion(sx, sy)
+}
+
+func (s *Seat) pointerMotion(sx, sy float64) {
+	s.sx, s.sy = sx, sy
+	for _, r := range resourcesOf(s.pointers, s.pointerFocus) {
+		r.PostEvent(2, s.time(), gen.WlFixed(sx), gen.WlFixed(sy))
+	}
+}
+
+// updatePointerFocus gives the pointer focus to the surface under the
+// pointer, once the pointer has been held elsewhere. Unlike MovePointer, it
+// sends no motion to a surface the pointer has not moved on.
+func (s *Seat) updatePointerFocus() {
+	surface, sx, sy := s.comp.surfaceAt(s.px, s.py)
+	switch {
+	case surface != s.pointerFocus:
+		s.setPointerFocus(surface, sx, sy)
+	case surface != nil && (sx != s.sx || sy != s.sy):
+		s.pointerMotion(sx, sy)
+	}
+}
+
+// setPointerFocus moves the pointer focus, with leave and enter events.
+func (s *Seat) setPointerFocus(surface *Surface, sx, sy float64) {
+	if old := s.pointerFocus; old != nil && !old.Resource().Destroyed() {
+		serial := s.serial(server.SerialPointerLeave, old, 0, false)
+		for _, r := range resourcesOf(s.pointers, old) {
+			r.PostEvent(1, serial, gen.WlObject(old.Resource().Id()))
+		}
+	}
+	s.pointerFocus = surface
+	// The cursor belongs to the client with the focus.
+	s.cursor, s.cursorSet = nil, false
+	if surface == nil {
+		return
+	}
+	s.sx, s.sy = sx, sy
+	serial := s.serial(server.SerialPointerEnter, surface, 0, false)
+	for _, r := range resourcesOf(s.pointers, surface) {
+		r.PostEvent(0, serial, gen.WlObject(surface.Resource().Id()), gen.WlFixed(sx), gen.WlFixed(sy))
+	}
+}
+
+// PressButton presses a pointer button over the focused surface.
+func (s *Seat) PressButton(button uint32) {
+	for _, b := range s.buttons {
+		if b == button {
+			return
+		}
+	}
+	s.buttons = append(s.buttons, button)
+	s.pointerButton(button, gen.WlPointerPressed)
+}
+
+// ReleaseButton releases a pointer button pressed with PressButton. When
+// the last button is released, the pointer focus follows the pointer
+// again.
+func (s *Seat) ReleaseButton(button uint32) {
+	for i, b := range s.buttons {
+		if b == button {
+			s.buttons = append(s.buttons[:i], s.buttons[i+1:]...)
+			s.pointerButton(button, gen.WlPointerReleased)
+			if len(s.buttons) == 0 {
+				if s.drag != nil {
+					s.drag.drop()
+				}
+				s.grab = nil
+				s.updatePointerFocus()
+			}
+			return
+		}
+	}
+}
+
+// Click presses and releases a pointer button.
+func (s *Seat) Click(button uint32) {
+	s.PressButton(button)
+	s.ReleaseButton(button)
+}
+
+func (s *Seat) pointerButton(button uint32, state gen.WlPointerButtonState) {
+	serial := s.serial(server.SerialPointerButton, s.pointerFocus, button, state == gen.WlPointerPressed)
+	for _, r := range resourcesOf(s.pointers, s.pointerFocus) {
+		r.PostEvent(3, serial, s.time(), gen.WlUint(button), gen.WlUint(state))
+	}
+}
+
+// Scroll scrolls along an axis by value, in the same units as motion.
+func (s *Seat) Scroll(axis gen.WlPointerAxis, value float64) {
+	for _, r := range resourcesOf(s.pointers, s.pointerFocus) {
+		r.PostEvent(4, s.time(), gen.WlUint(axis), gen.WlFixed(value))
+	}
+}
+
+// KeyboardFocus returns the surface receiving keyboard input, or nil.
+func (s *Seat) KeyboardFocus() *Surface {
+	return s.keyboardFocus
+}
+
+// SetKeyboardFocus gives the keyboard focus to a surface, or takes it away
+// if surface is nil. The surface gets the keys currently pressed and the
+// modifiers on enter.
+func (s *Seat) SetKeyboardFocus(surface *Surface) {
+	if surface == s.keyboardFocus {
+		return
+	}
+	if old := s.keyboardFocus; old != nil && !old.Resource().Destroyed() {
+		serial := s.serial(server.SerialKeyboardLeave, old, 0, false)
+		for _, r := range resourcesOf(s.keyboards, old) {
+			r.PostEvent(2, serial, gen.WlObject(old.Resource().Id()))
+		}
+	}
+	s.keyboardFocus = surface
+	for _, r := range resourcesOf(s.keyboards, surface) {
+		s.keyboardEnter(r)
+	}
+	for _, dev := range resourcesOf(s.dataDevices, surface) {
+		s.sendSelection(dev)
+	}
+}
+
+// keyboardEnter sends enter and the modifiers to a keyboard of the focused
+// client.
+func (s *Seat) keyboardEnter(r *server.Resource) {
+	keys := make([]byte, 4*len(s.keys))
+	for i, k := range s.keys {
+		binary.LittleEndian.PutUint32(keys[4*i:], k)
+	}
+	f := s.keyboardFocus
+	r.PostEvent(1, s.serial(server.SerialKeyboardEnter, f, 0, false), gen.WlObject(f.Resource().Id()), gen.WlArray(keys))
+	m := s.mods
+	r.PostEvent(4, s.serial(server.SerialModifiers, f, 0, false), gen.WlUint(m[0]), gen.WlUint(m[1]), gen.WlUint(m[2]), gen.WlUint(m[3]))
+}
+
+// PressKey presses a key, given as a Linux evdev code.
+func (s *Seat) PressKey(key uint32) {
+	for _, k := range s.keys {
+		if k == key {
+			return
+		}
+	}
+	s.keys = append(s.keys, key)
+	s.key(key, gen.WlKeyboardPressed)
+}
+
+// ReleaseKey releases a key pressed with PressKey.
+func (s *Seat) ReleaseKey(key uint32) {
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			s.key(key, gen.WlKeyboardReleased)
+			return
+		}
+	}
+}
+
+func (s *Seat) key(key uint32, state gen.WlKeyboardKeyState) {
+	serial := s.serial(server.SerialKey, s.keyboardFocus, key, state == gen.WlKeyboardPressed)
+	for _, r := range resourcesOf(s.keyboards, s.keyboardFocus) {
+		r.PostEvent(3, serial, s.time(), gen.WlUint(key), gen.WlUint(state))
+	}
+}
+
+// Modifiers returns the modifier state set with SetModifiers.
+func (s *Seat) Modifiers() (depressed, latched, locked, group uint32) {
+	return s.mods[0], s.mods[1], s.mods[2], s.mods[3]
+}
+
+// SetModifiers sets the modifier state, as masks of the Mod constants, and
+// sends it to the focused surface. The seat does not derive modifiers from
+// pressed keys, so pressing KEY_LEFTSHIFT alone does not shift.
+func (s *Seat) SetModifiers(depressed, latched, locked, group uint32) {
+	m := [4]uint32{depressed, latched, locked, group}
+	if m == s.mods {
+		return
+	}
+	s.mods = m
+	serial := s.serial(server.SerialModifiers, s.keyboardFocus, 0, false)
+	for _, r := range resourcesOf(s.keyboards, s.keyboardFocus) {
+		r.PostEvent(4, serial, gen.WlUint(m[0]), gen.WlUint(m[1]), gen.WlUint(m[2]), gen.WlUint(m[3]))
+	}
+}
+
+// TypeKey presses and releases a key with the given modifiers held, and
+// then restores the modifiers.
+func (s *Seat) TypeKey(key uint32, mods uint32) {
+	saved := s.mods
+	s.SetModifiers(saved[0]|mods, saved[1], saved[2], saved[3])
+	s.PressKey(key)
+	s.ReleaseKey(key)
+	s.SetModifiers(saved[0], saved[1], saved[2], saved[3])
+}
+
+// TouchDown starts a touch point at x, y on the output. The point belongs
+// to the surface under it until it is lifted, even if it moves off it.
+// Touching where there is no surface is ignored.
+func (s *Seat) TouchDown(id int32, x, y float64) {
+	surface, sx, sy := s.comp.surfaceAt(x, y)
+	if surface == nil {
+		return
+	}
+	if s.points == nil {
+		s.points = make(map[int32]*touchPoint)
+	}
+	ox, oy := surface.outputPosition()
+	s.points[id] = &touchPoint{surface, ox, oy}
+	serial := s.serial(server.SerialTouchDown, surface, uint32(id), true)
+	for _, r := range resourcesOf(s.touches, surface) {
+		r.PostEvent(0, serial, s.time(), gen.WlObject(surface.Resource().Id()), gen.WlInt(id), gen.WlFixed(sx), gen.WlFixed(sy))
+		r.PostEvent(3)
+	}
+}
+
+// TouchMotion moves a touch point to x, y on the output.
+func (s *Seat) TouchMotion(id int32, x, y float64) {
+	tp := s.points[id]
+	if tp == nil {
+		return
+	}
+	sx, sy := x-float64(tp.ox), y-float64(tp.oy)
+	for _, r := range resourcesOf(s.touches, tp.surface) {
+		r.PostEvent(2, s.time(), gen.WlInt(id), gen.WlFixed(sx), gen.WlFixed(sy))
+		r.PostEvent(3)
+	}
+}
+
+// TouchUp lifts a touch point.
+func (s *Seat) TouchUp(id int32) {
+	tp := s.points[id]
+	if tp == nil {
+		return
+	}
+	delete(s.points, id)
+	serial := s.serial(server.SerialTouchUp, tp.surface, uint32(id), false)
+	for _, r := range resourcesOf(s.touches, tp.surface) {
+		r.PostEvent(1, serial, s.time(), gen.WlInt(id))
+		r.PostEvent(3)
+	}
+}
+
+// TouchCancel cancels every touch point, as when the compositor takes over
+// a gesture.
+func (s *Seat) TouchCancel() {
+	done := make(map[*server.Resource]bool)
+	for id, tp := range s.points {
+		delete(s.points, id)
+		for _, r := range resourcesOf(s.touches, tp.surface) {
+			if !done[r] {
+				done[r] = true
+				r.PostEvent(4)
+			}
+		}
+	}
+}
+
+// surfaceGone drops the input focus of a surface that is unmapped or
+// destroyed. A surface that is still alive gets leave events.
+func (s *Seat) surfaceGone(surface *Surface) {
+	within := func(v *Surface) bool { return v != nil && v.isDescendantOf(surface) }
+	if within(s.pointerFocus) {
+		s.buttons = nil
+		s.setPointerFocus(nil, 0, 0)
+	}
+	if within(s.keyboardFocus) {
+		s.SetKeyboardFocus(nil)
+	}
+	for id, tp := range s.points {
+		if within(tp.surface) {
+			delete(s.points, id)
+		}
+	}
+	if s.drag != nil {
+		s.drag.surfaceGone(surface)
+	}
+	if s.grab != nil && s.grab.sh.surface == surface {
+		s.grab = nil
+	}
+	if s.cursor == surface {
+		s.cursor = nil
+	}
+}
+
+// newPointer and newKeyboard send enter to input objects that a client
+// creates while it has the focus.
+func (s *Seat) newPointer(r *server.Resource) {
+	if f := s.pointerFocus; f != nil && f.Resource().Client() == r.Client() {
+		sx, sy := f.local(s.px, s.py)
+		r.PostEvent(0, s.serial(server.SerialPointerEnter, f, 0, false), gen.WlObject(f.Resource().Id()), gen.WlFixed(sx), gen.WlFixed(sy))
+	}
+}
+
+func (s *Seat) newKeyboard(r *server.Resource) {
+	if f := s.keyboardFocus; f != nil && f.Resource().Client() == r.Client() {
+		s.keyboardEnter(r)
+	}
+}
+
+// surfaceAt returns the topmost surface accepting input at x, y on the
+// output, and the point in its coordinates.
+func (c *Compositor) surfaceAt(x, y float64) (*Surface, float64, float64) {
+	for i := len(c.toplevels) - 1; i >= 0; i-- {
+		t := c.toplevels[i]
+		if s, sx, sy := t.pick(x-float64(t.x), y-float64(t.y)); s != nil {
+			return s, sx, sy
+		}
+	}
+	return nil, 0, 0
+}
+
+// pick finds the topmost surface of the tree rooted at s accepting input at
+// x, y relative to s.
+func (s *Surface) pick(x, y float64) (*Surface, float64, float64) {
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		v := s.stack[i]
+		if v == s {
+			if s.image != nil && s.core.AcceptsInput(int(math.Floor(x)), int(math.Floor(y))) {
+				return s, x, y
+			}
+		} else if v.image != nil {
+			if hit, hx, hy := v.pick(x-float64(v.x), y-float64(v.y)); hit != nil {
+				return hit, hx, hy
+			}
+		}
+	}
+	return nil, 0, 0
+}
+
+// outputPosition returns where the surface's top-left corner is on the
+// output.
+func (s *Surface) outputPosition() (x, y int) {
+	for v := s; ; v = v.parent.parent {
+		x += v.x
+		y += v.y
+		if v.parent == nil || v.parent.parent == nil {
+			return x, y
+		}
+	}
+}
+
+// local translates a point on the output to surface coordinates.
+func (s *Surface) local(x, y float64) (float64, float64) {
+	ox, oy := s.outputPosition()
+	return x - float64(ox), y - float64(oy)
+}
@@ -0,0 +1,89 @@
+package headless
+
+import (
+	"image"
+
+	"github.com/Pursuit92/goland/gen/server"
+)
+
+// AddOutput plugs in a virtual output. Surfaces that overlap it enter it on
+// the next repaint.
+func (c *Compositor) AddOutput(info server.OutputInfo) (*server.Output, error) {
+	o, err := c.display.AddOutput(info)
+	if err != nil {
+		return nil, err
+	}
+	c.outputs = append(c.outputs, o)
+	c.scheduleRepaint()
+	return o, nil
+}
+
+// RemoveOutput unplugs an output added with AddOutput or created by New.
+func (c *Compositor) RemoveOutput(o *server.Output) {
+	for i, v := range c.outputs {
+		if v == o {
+			c.outputs = append(c.outputs[:i], c.outputs[i+1:]...)
+			o.Remove()
+			c.scheduleRepaint()
+			return
+		}
+	}
+}
+
+// Outputs returns the plugged in outputs, in the order they were added.
+func (c *Compositor) Outputs() []*server.Output {
+	return append([]*server.Output(nil), c.outputs...)
+}
+
+// Output returns the first plugged in output, or nil if there is none.
+func (c *Compositor) Output() *server.Output {
+	if len(c.outputs) == 0 {
+		return nil
+	}
+	return c.outputs[0]
+}
+
+// outputSize returns the size shells fill for fullscreen and maximized
+// surfaces: the first output's, or the framebuffer's without outputs.
+func (c *Compositor) outputSize() (width, height int) {
+	if o := c.Output(); o != nil {
+		b := o.Bounds()
+		return b.Dx(), b.Dy()
+	}
+	return c.fb.Rect.Dx(), c.fb.Rect.Dy()
+}
+
+// updateOutputs sends wl_surface.enter and leave as mapped surfaces move
+// onto and off the outputs.
+func (c *Compositor) updateOutputs() {
+	type entry struct {
+		s *server.Surface
+		o *server.Output
+	}
+	var on []entry
+	want := make(map[entry]bool)
+	for _, t := range c.toplevels {
+		x, y := t.Position()
+		t.walk(x, y, func(s *Surface, x, y int) {
+			w, h := s.Size()
+			r := image.Rect(x, y, x+w, y+h)
+			for _, o := range c.outputs {
+				if r.Overlaps(o.Bounds()) {
+					e := entry{s.core, o}
+					on = append(on, e)
+					want[e] = true
+				}
+			}
+		})
+	}
+	for _, o := range c.outputs {
+		for _, s := range o.Surfaces() {
+			if !want[entry{s, o}] {
+				s.LeaveOutput(o)
+			}
+		}
+	}
+	for _, e := range on {
+		e.s.EnterOutput(e.o)
+	}
+}
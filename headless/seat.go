@@ -0,0 +1,145 @@
+package headless
+
+import (
+	"os"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/server"
+)
+
+// A Seat is a wl_seat with a pointer, a keyboard and a touch screen. The
+// headless compositor has no input devices of its own; input is injected
+// with the Seat's methods, which like the rest of the compositor must be
+// called on the display's goroutine, for instance through Display.Invoke.
+// Positions are in the compositor's global space; events carry them
+// translated to the receiving surface. The seat handles focus, serials and
+// timestamps, and the selection and drag-and-drop of its data devices.
+type Seat struct {
+	comp   *Compositor
+	global *server.Global
+	name   string
+
+	pointers  []*server.Resource
+	keyboards []*server.Resource
+	touches   []*server.Resource
+
+	serials *server.SerialTracker
+	inputState
+	dataState
+}
+
+func newSeat(c *Compositor, name string) (*Seat, error) {
+	s := &Seat{comp: c, name: name, serials: server.NewSerialTracker(c.display)}
+	g, err := c.display.AddGlobal(gen.WlSeatInterface, 4, s.bind)
+	if err != nil {
+		return nil, err
+	}
+	s.global = g
+	return s, nil
+}
+
+// Name returns the seat's name.
+func (s *Seat) Name() string {
+	return s.name
+}
+
+// Serials returns the tracker of the serials the seat has sent, against
+// which requests starting grabs are checked.
+func (s *Seat) Serials() *server.SerialTracker {
+	return s.serials
+}
+
+// Global returns the wl_seat global.
+func (s *Seat) Global() *server.Global {
+	return s.global
+}
+
+func (s *Seat) bind(r *server.Resource) {
+	r.SetImplementation(seatImpl{s, r})
+	caps := gen.WlSeatPointer | gen.WlSeatKeyboard | gen.WlSeatTouch
+	r.PostEvent(0, gen.WlUint(caps))
+	if r.Version() >= 2 {
+		r.PostEvent(1, gen.WlString(s.name))
+	}
+}
+
+// track adds r to list and removes it again when it is destroyed.
+func track(list *[]*server.Resource, r *server.Resource) {
+	*list = append(*list, r)
+	r.AddDestroyListener(func(r *server.Resource) {
+		for i, v := range *list {
+			if v == r {
+				*list = append((*list)[:i], (*list)[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// seatImpl implements wl_seat.
+type seatImpl struct {
+	s   *Seat
+	res *server.Resource
+}
+
+func (si seatImpl) GetPointer(Id gen.WlNewId) {
+	r, err := si.res.Client().NewResource(uint32(Id), gen.WlPointerInterface, si.res.Version())
+	if err != nil {
+		return
+	}
+	r.SetImplementation(pointerImpl{si.s, r})
+	track(&si.s.pointers, r)
+	si.s.newPointer(r)
+}
+
+func (si seatImpl) GetKeyboard(Id gen.WlNewId) {
+	r, err := si.res.Client().NewResource(uint32(Id), gen.WlKeyboardInterface, si.res.Version())
+	if err != nil {
+		return
+	}
+	track(&si.s.keyboards, r)
+
+	// There is no keymap to share: clients are told so with an empty file.
+	if f, err := os.Open(os.DevNull); err == nil {
+		r.PostEvent(0, gen.WlUint(gen.WlKeyboardNoKeymap), gen.WlFd(f.Fd()), gen.WlUint(0))
+		f.Close()
+	}
+	if r.Version() >= 4 {
+		r.PostEvent(5, gen.WlInt(25), gen.WlInt(600))
+	}
+	si.s.newKeyboard(r)
+}
+
+func (si seatImpl) GetTouch(Id gen.WlNewId) {
+	r, err := si.res.Client().NewResource(uint32(Id), gen.WlTouchInterface, si.res.Version())
+	if err != nil {
+		return
+	}
+	track(&si.s.touches, r)
+}
+
+// pointerImpl implements wl_pointer.
+type pointerImpl struct {
+	s   *Seat
+	res *server.Resource
+}
+
+func (pi pointerImpl) SetCursor(Serial gen.WlUint, Surface gen.WlObject, HotspotX gen.WlInt, HotspotY gen.WlInt) {
+	// Only the client with the pointer focus may set the cursor, and only
+	// in answer to the current enter.
+	s := pi.s
+	client := pi.res.Client()
+	if f := s.pointerFocus; f == nil || f.Resource().Client() != client ||
+		!s.serials.IsLatest(uint32(Serial), server.SerialPointerEnter, client) {
+		return
+	}
+	if Surface == 0 {
+		// A null surface hides the cursor.
+		s.setCursor(nil, int(HotspotX), int(HotspotY))
+		return
+	}
+	cursor := SurfaceFromResource(client.Resource(uint32(Surface)))
+	if cursor.core.SetRole(RoleCursor, pi.res, uint32(gen.WlPointerRole)) {
+		s.setCursor(cursor, int(HotspotX), int(HotspotY))
+	}
+}
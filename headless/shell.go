@@ -0,0 +1,442 @@
+package headless
+
+import (
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/server"
+)
+
+// Role names, as reported by Surface.Role.
+const (
+	RoleShellSurface = "wl_shell_surface"
+	RoleSubsurface   = "wl_subsurface"
+	RoleCursor       = "wl_pointer-cursor"
+)
+
+func (c *Compositor) bindSubcompositor(r *server.Resource) {
+	r.SetImplementation(subcompositorImpl{c, r})
+}
+
+// subcompositorImpl implements wl_subcompositor.
+type subcompositorImpl struct {
+	c   *Compositor
+	res *server.Resource
+}
+
+func (si subcompositorImpl) GetSubsurface(Id gen.WlNewId, Surface gen.WlObject, Parent gen.WlObject) {
+	client := si.res.Client()
+	surface := SurfaceFromResource(client.Resource(uint32(Surface)))
+	parent := SurfaceFromResource(client.Resource(uint32(Parent)))
+	bad := uint32(gen.WlSubcompositorBadSurface)
+	switch {
+	case surface == parent:
+		si.res.PostError(bad, "wl_surface@%d cannot be its own parent", Surface)
+		return
+	case surface.parent != nil:
+		si.res.PostError(bad, "wl_surface@%d is already a sub-surface", Surface)
+		return
+	case parent.isDescendantOf(surface):
+		si.res.PostError(bad, "wl_surface@%d is an ancestor of parent", Surface)
+		return
+	}
+	if !surface.core.SetRole(RoleSubsurface, si.res, bad) {
+		return
+	}
+	r, err := client.NewResource(uint32(Id), gen.WlSubsurfaceInterface, si.res.Version())
+	if err != nil {
+		return
+	}
+	sub := &Subsurface{res: r, surface: surface, parent: parent, sync: true}
+	surface.parent = sub
+	surface.core.SetRoleObject(sub)
+	surface.x, surface.y = 0, 0
+	parent.stack = append(parent.stack, surface)
+	parent.pendingStack = append(parent.pendingStack, surface)
+	r.SetImplementation(subsurfaceImpl{sub})
+	r.AddDestroyListener(func(*server.Resource) { sub.destroy() })
+	si.c.scheduleRepaint()
+}
+
+// isDescendantOf reports whether s is a, possibly indirect, subsurface of
+// other, or other itself.
+func (s *Surface) isDescendantOf(other *Surface) bool {
+	for v := s; v != nil; {
+		if v == other {
+			return true
+		}
+		if v.parent == nil {
+			return false
+		}
+		v = v.parent.parent
+	}
+	return false
+}
+
+// A Subsurface is the wl_subsurface role of a surface, placing it relative
+// to its parent.
+type Subsurface struct {
+	res     *server.Resource
+	surface *Surface
+	parent  *Surface
+
+	x, y   int
+	posSet bool
+	sync   bool
+}
+
+// Parent returns the parent surface, or nil once the parent is destroyed.
+func (sub *Subsurface) Parent() *Surface {
+	return sub.parent
+}
+
+// Sync reports whether the subsurface is in synchronized mode.
+func (sub *Subsurface) Sync() bool {
+	return sub.sync
+}
+
+// Synchronized reports whether the surface's commits wait for its parent's:
+// whether it or any of its ancestors is in synchronized mode.
+func (sub *Subsurface) Synchronized() bool {
+	for v := sub; v != nil; {
+		if v.sync {
+			return true
+		}
+		if v.parent == nil {
+			return false
+		}
+		v = v.parent.parent
+	}
+	return false
+}
+
+// Committed implements server.SurfaceRole. The surface's contents have
+// already been taken up, and its position follows its parent's commits.
+func (sub *Subsurface) Committed(*server.Surface) {}
+
+// parentCommitted applies a position set with set_position and, in
+// synchronized mode, the surface's held-back commits.
+func (sub *Subsurface) parentCommitted() {
+	if sub.posSet {
+		sub.surface.x, sub.surface.y = sub.x, sub.y
+		sub.posSet = false
+	}
+	if sub.Synchronized() {
+		sub.surface.core.ApplyCached()
+	}
+}
+
+// destroy unlinks the surface from its parent when the wl_subsurface is
+// destroyed. The surface keeps its role.
+func (sub *Subsurface) destroy() {
+	if sub.surface != nil {
+		sub.surface.comp.seat.surfaceGone(sub.surface)
+		sub.surface.parent = nil
+		sub.surface.core.SetRoleObject(nil)
+	}
+	if sub.parent != nil {
+		sub.parent.removeChild(sub.surface)
+		sub.parent.comp.scheduleRepaint()
+	}
+	sub.surface, sub.parent = nil, nil
+}
+
+// destroySurface is called when the subsurface's surface is destroyed,
+// which leaves the wl_subsurface inert.
+func (sub *Subsurface) destroySurface() {
+	if sub.parent != nil {
+		sub.parent.removeChild(sub.surface)
+	}
+	sub.surface, sub.parent = nil, nil
+}
+
+// parentDestroyed unmaps the subsurface when its parent goes away.
+func (sub *Subsurface) parentDestroyed() {
+	sub.parent = nil
+}
+
+// subsurfaceImpl implements wl_subsurface.
+type subsurfaceImpl struct {
+	sub *Subsurface
+}
+
+func (si subsurfaceImpl) SetPosition(X gen.WlInt, Y gen.WlInt) {
+	si.sub.x, si.sub.y = int(X), int(Y)
+	si.sub.posSet = true
+}
+
+func (si subsurfaceImpl) PlaceAbove(Sibling gen.WlObject) {
+	si.place(Sibling, 1)
+}
+
+func (si subsurfaceImpl) PlaceBelow(Sibling gen.WlObject) {
+	si.place(Sibling, 0)
+}
+
+// place moves the surface next to sibling in its parent's pending stack,
+// just above it if offset is 1 or just below if 0.
+func (si subsurfaceImpl) place(sibling gen.WlObject, offset int) {
+	sub := si.sub
+	if sub.parent == nil {
+		return
+	}
+	other := SurfaceFromResource(sub.res.Client().Resource(uint32(sibling)))
+	parent := sub.parent
+	if other == sub.surface || (other != parent && (other.parent == nil || other.parent.parent != parent)) {
+		sub.res.PostError(uint32(gen.WlSubsurfaceBadSurface), "wl_surface@%d is not a sibling or the parent", sibling)
+		return
+	}
+	stack := removeSurface(parent.pendingStack, sub.surface)
+	for i, v := range stack {
+		if v == other {
+			i += offset
+			stack = append(stack[:i], append([]*Surface{sub.surface}, stack[i:]...)...)
+			break
+		}
+	}
+	parent.pendingStack = stack
+}
+
+func (si subsurfaceImpl) SetSync() {
+	si.sub.sync = true
+}
+
+func (si subsurfaceImpl) SetDesync() {
+	sub := si.sub
+	sub.sync = false
+	if sub.surface != nil && !sub.Synchronized() {
+		sub.surface.core.ApplyCached()
+	}
+}
+
+func (c *Compositor) bindShell(r *server.Resource) {
+	r.SetImplementation(shellImpl{c, r})
+}
+
+// shellImpl implements wl_shell.
+type shellImpl struct {
+	c   *Compositor
+	res *server.Resource
+}
+
+func (si shellImpl) GetShellSurface(Id gen.WlNewId, Surface gen.WlObject) {
+	client := si.res.Client()
+	surface := SurfaceFromResource(client.Resource(uint32(Surface)))
+	if _, ok := surface.core.RoleObject().(*ShellSurface); ok {
+		si.res.PostError(uint32(gen.WlShellRole), "wl_surface@%d already has a shell surface", Surface)
+		return
+	}
+	if !surface.core.SetRole(RoleShellSurface, si.res, uint32(gen.WlShellRole)) {
+		return
+	}
+	r, err := client.NewResource(uint32(Id), gen.WlShellSurfaceInterface, si.res.Version())
+	if err != nil {
+		return
+	}
+	sh := &ShellSurface{comp: si.c, res: r, surface: surface}
+	surface.core.SetRoleObject(sh)
+	r.SetImplementation(shellSurfaceImpl{sh})
+	r.AddDestroyListener(func(*server.Resource) {
+		if sh.surface != nil {
+			sh.surface.core.SetRoleObject(nil)
+			si.c.unmapSurface(sh.surface)
+		}
+	})
+}
+
+// Shell surface kinds, as reported by ShellSurface.Kind.
+const (
+	ShellNone = iota
+	ShellToplevel
+	ShellTransient
+	ShellPopup
+	ShellFullscreen
+	ShellMaximized
+)
+
+// A ShellSurface is the wl_shell_surface role of a surface. It is mapped,
+// and so drawn, once it has a kind and a buffer.
+type ShellSurface struct {
+	comp    *Compositor
+	res     *server.Resource
+	surface *Surface
+
+	kind   int
+	parent *Surface
+	px, py int
+	title  string
+	class  string
+}
+
+// Surface returns the surface the role belongs to.
+func (sh *ShellSurface) Surface() *Surface {
+	return sh.surface
+}
+
+// Kind returns how the surface was last asked to be shown.
+func (sh *ShellSurface) Kind() int {
+	return sh.kind
+}
+
+// Title returns the title set by the client.
+func (sh *ShellSurface) Title() string {
+	return sh.title
+}
+
+// Class returns the class set by the client.
+func (sh *ShellSurface) Class() string {
+	return sh.class
+}
+
+// Committed implements server.SurfaceRole: it maps or unmaps the surface
+// after its state changed.
+func (sh *ShellSurface) Committed(*server.Surface) {
+	s := sh.surface
+	if sh.kind == ShellNone || s.image == nil {
+		sh.comp.unmapSurface(s)
+		return
+	}
+	if sh.parent != nil && (sh.kind == ShellTransient || sh.kind == ShellPopup) {
+		x, y := sh.parent.Position()
+		s.x, s.y = x+sh.px, y+sh.py
+	}
+	sh.comp.mapSurface(s)
+}
+
+// setKind records how the surface should be shown; the position follows on
+// the next commit.
+func (sh *ShellSurface) setKind(kind int) {
+	sh.kind = kind
+	sh.parent = nil
+	if kind == ShellToplevel || kind == ShellFullscreen || kind == ShellMaximized {
+		sh.surface.x, sh.surface.y = 0, 0
+	}
+	if kind == ShellFullscreen || kind == ShellMaximized {
+		w, h := sh.comp.outputSize()
+		sh.res.PostEvent(1, gen.WlUint(gen.WlShellSurfaceNone), gen.WlInt(w), gen.WlInt(h))
+	}
+}
+
+// shellSurfaceImpl implements wl_shell_surface.
+type shellSurfaceImpl struct {
+	sh *ShellSurface
+}
+
+func (si shellSurfaceImpl) Pong(Serial gen.WlUint) {}
+
+func (si shellSurfaceImpl) Move(Seat gen.WlObject, Serial gen.WlUint) {
+	si.sh.startGrab(uint32(Serial), 0)
+}
+
+func (si shellSurfaceImpl) Resize(Seat gen.WlObject, Serial gen.WlUint, Edges gen.WlUint) {
+	if Edges == 0 {
+		return
+	}
+	si.sh.startGrab(uint32(Serial), gen.WlShellSurfaceResize(Edges))
+}
+
+// A shellGrab moves or resizes a shell surface with the pointer until the
+// button is released, for wl_shell_surface.move and resize.
+type shellGrab struct {
+	sh *ShellSurface
+	// edges are the edges being dragged, zero for a move.
+	edges gen.WlShellSurfaceResize
+	// px and py are where the pointer was at the start, and x, y, w and h
+	// the surface's position and size.
+	px, py     float64
+	x, y, w, h int
+}
+
+// startGrab starts a move or resize if serial is that of a button press on
+// the surface, or one of its subsurfaces, which is still held.
+func (sh *ShellSurface) startGrab(serial uint32, edges gen.WlShellSurfaceResize) {
+	s := sh.comp.seat
+	rec, ok := s.serials.ImplicitGrab(serial, sh.res.Client())
+	if !ok || rec.Kind != server.SerialPointerButton || s.grab != nil || s.drag != nil {
+		return
+	}
+	if origin, _ := rec.Surface.UserData().(*Surface); origin == nil || !origin.isDescendantOf(sh.surface) {
+		return
+	}
+	if sh.kind != ShellToplevel {
+		return
+	}
+	w, h := sh.surface.Size()
+	s.grab = &shellGrab{sh: sh, edges: edges, px: s.px, py: s.py, x: sh.surface.x, y: sh.surface.y, w: w, h: h}
+}
+
+// motion moves the surface along with the pointer, or asks the client for
+// the size the pointer gives it.
+func (g *shellGrab) motion(x, y float64) {
+	dx, dy := int(x-g.px), int(y-g.py)
+	if g.edges == 0 {
+		g.sh.surface.x, g.sh.surface.y = g.x+dx, g.y+dy
+		g.sh.comp.scheduleRepaint()
+		return
+	}
+	w, h := g.w, g.h
+	switch {
+	case g.edges&gen.WlShellSurfaceLeft != 0:
+		w -= dx
+	case g.edges&gen.WlShellSurfaceRight != 0:
+		w += dx
+	}
+	switch {
+	case g.edges&gen.WlShellSurfaceTop != 0:
+		h -= dy
+	case g.edges&gen.WlShellSurfaceBottom != 0:
+		h += dy
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	g.sh.res.PostEvent(1, gen.WlUint(g.edges), gen.WlInt(w), gen.WlInt(h))
+}
+
+func (si shellSurfaceImpl) SetToplevel() {
+	si.sh.setKind(ShellToplevel)
+}
+
+func (si shellSurfaceImpl) SetTransient(Parent gen.WlObject, X gen.WlInt, Y gen.WlInt, Flags gen.WlUint) {
+	si.setChild(ShellTransient, Parent, X, Y)
+}
+
+func (si shellSurfaceImpl) SetFullscreen(Method gen.WlUint, Framerate gen.WlUint, Output gen.WlObject) {
+	si.sh.setKind(ShellFullscreen)
+}
+
+func (si shellSurfaceImpl) SetPopup(Seat gen.WlObject, Serial gen.WlUint, Parent gen.WlObject, X gen.WlInt, Y gen.WlInt, Flags gen.WlUint) {
+	// A popup grabs the pointer, so like a move it must answer a button
+	// press on its parent, or one of the parent's subsurfaces, that is
+	// still held. Every wl_seat is the compositor's one seat.
+	client := si.sh.res.Client()
+	rec, ok := si.sh.comp.seat.serials.ImplicitGrab(uint32(Serial), client)
+	parent := SurfaceFromResource(client.Resource(uint32(Parent)))
+	if !ok || rec.Kind != server.SerialPointerButton || parent == nil {
+		return
+	}
+	if origin, _ := rec.Surface.UserData().(*Surface); origin == nil || !origin.isDescendantOf(parent) {
+		return
+	}
+	si.setChild(ShellPopup, Parent, X, Y)
+}
+
+func (si shellSurfaceImpl) setChild(kind int, parent gen.WlObject, x, y gen.WlInt) {
+	sh := si.sh
+	sh.setKind(kind)
+	sh.parent = SurfaceFromResource(sh.res.Client().Resource(uint32(parent)))
+	sh.px, sh.py = int(x), int(y)
+}
+
+func (si shellSurfaceImpl) SetMaximized(Output gen.WlObject) {
+	si.sh.setKind(ShellMaximized)
+}
+
+func (si shellSurfaceImpl) SetTitle(Title gen.WlString) {
+	si.sh.title = string(Title)
+}
+
+func (si shellSurfaceImpl) SetClass(Class gen.WlString) {
+	si.sh.class = string(Class)
+}
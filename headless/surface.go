@@ -0,0 +1,200 @@
+package headless
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/Pursuit92/goland/gen/server"
+)
+
+// A Surface is the compositor's view of a client's wl_surface: its contents
+// and place on the output. The double-buffered protocol state is kept by the
+// server.Surface it wraps.
+type Surface struct {
+	comp *Compositor
+	core *server.Surface
+
+	// image holds the contents of the last committed buffer; the buffer
+	// itself is released as soon as it has been copied.
+	image *image.RGBA
+
+	// x and y are the position of a mapped toplevel on the output, or of
+	// a subsurface relative to its parent.
+	x, y int
+	// parent is set for subsurfaces.
+	parent *Subsurface
+	// stack holds the surface and its subsurfaces, bottom to top.
+	// pendingStack is the order requested by place_above and place_below,
+	// which takes effect on commit.
+	stack        []*Surface
+	pendingStack []*Surface
+}
+
+func newSurface(c *Compositor, r *server.Resource) *Surface {
+	core := server.NewSurface(r)
+	s := &Surface{comp: c, core: core}
+	s.stack = []*Surface{s}
+	s.pendingStack = []*Surface{s}
+	core.SetUserData(s)
+	core.AddCommitListener(s.committed)
+	r.AddDestroyListener(func(*server.Resource) { s.destroyed() })
+	return s
+}
+
+// SurfaceFromResource returns the Surface behind a wl_surface resource.
+func SurfaceFromResource(r *server.Resource) *Surface {
+	core := server.SurfaceFromResource(r)
+	if core == nil {
+		return nil
+	}
+	s, _ := core.UserData().(*Surface)
+	return s
+}
+
+// Resource returns the surface's wl_surface resource.
+func (s *Surface) Resource() *server.Resource {
+	return s.core.Resource()
+}
+
+// Core returns the surface's protocol state.
+func (s *Surface) Core() *server.Surface {
+	return s.core
+}
+
+// Role returns the name of the surface's role, or "" if it has none.
+func (s *Surface) Role() string {
+	return s.core.Role()
+}
+
+// Position returns the position of a toplevel on the output, or of a
+// subsurface relative to its parent.
+func (s *Surface) Position() (x, y int) {
+	return s.x, s.y
+}
+
+// Size returns the surface size: the committed buffer's size divided by
+// the buffer scale.
+func (s *Surface) Size() (w, h int) {
+	return s.core.Size()
+}
+
+// Image returns the surface contents, in buffer pixels, or nil if no buffer
+// is attached.
+func (s *Surface) Image() *image.RGBA {
+	return s.image
+}
+
+func (s *Surface) destroyed() {
+	s.comp.seat.surfaceGone(s)
+	s.comp.unmapSurface(s)
+	if s.parent != nil {
+		s.parent.destroySurface()
+	}
+	for _, child := range append([]*Surface(nil), s.pendingStack...) {
+		if child != s && child.parent != nil {
+			child.parent.parentDestroyed()
+		}
+	}
+	s.stack, s.pendingStack = nil, nil
+	s.comp.scheduleRepaint()
+}
+
+// committed takes up the state of an applied commit.
+func (s *Surface) committed(core *server.Surface) {
+	if core.Attached() {
+		s.image = nil
+		if buf := core.Buffer(); buf != nil {
+			s.image = copyBuffer(buf)
+		}
+		core.ReleaseBuffer()
+		dx, dy := core.BufferOffset()
+		s.x += dx
+		s.y += dy
+	}
+	core.ClearDamage()
+	if core.HasFrameCallbacks() {
+		s.comp.frames = append(s.comp.frames, core)
+	}
+
+	s.stack = append(s.stack[:0], s.pendingStack...)
+	for _, child := range s.stack {
+		if child != s {
+			child.parent.parentCommitted()
+		}
+	}
+	s.comp.scheduleRepaint()
+}
+
+// copyBuffer copies a shm buffer's contents.
+func copyBuffer(buf *server.Resource) *image.RGBA {
+	b := server.ShmBufferFromResource(buf)
+	if b == nil {
+		return nil
+	}
+	var img *image.RGBA
+	b.Access(func() {
+		src, err := b.Image()
+		if err != nil {
+			return
+		}
+		img = image.NewRGBA(src.Bounds())
+		draw.Draw(img, img.Rect, src, image.Point{}, draw.Src)
+	})
+	return img
+}
+
+// removeChild takes a subsurface out of the surface's stacks.
+func (s *Surface) removeChild(child *Surface) {
+	s.stack = removeSurface(s.stack, child)
+	s.pendingStack = removeSurface(s.pendingStack, child)
+}
+
+func removeSurface(list []*Surface, s *Surface) []*Surface {
+	for i, v := range list {
+		if v == s {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// drawTree draws the surface and its subsurfaces with the surface's
+// top-left corner at x, y.
+func (s *Surface) drawTree(dst *image.RGBA, x, y int) {
+	s.walk(x, y, func(v *Surface, x, y int) { v.draw(dst, x, y) })
+}
+
+// walk calls f for the surface and its mapped subsurfaces, bottom to top,
+// with their top-left corners relative to the surface's at x, y.
+func (s *Surface) walk(x, y int, f func(v *Surface, x, y int)) {
+	for _, v := range s.stack {
+		if v == s {
+			f(s, x, y)
+		} else if v.image != nil {
+			v.walk(x+v.x, y+v.y, f)
+		}
+	}
+}
+
+// draw composites the surface contents, scaled down by the buffer scale
+// with nearest-neighbour sampling.
+func (s *Surface) draw(dst *image.RGBA, x, y int) {
+	if s.image == nil {
+		return
+	}
+	scale := int(s.core.Scale())
+	if scale <= 1 {
+		r := s.image.Rect.Add(image.Pt(x, y))
+		draw.Draw(dst, r, s.image, image.Point{}, draw.Over)
+		return
+	}
+	w, h := s.Size()
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	for sy := 0; sy < h; sy++ {
+		for sx := 0; sx < w; sx++ {
+			i := s.image.PixOffset(sx*scale, sy*scale)
+			copy(scaled.Pix[scaled.PixOffset(sx, sy):], s.image.Pix[i:i+4])
+		}
+	}
+	draw.Draw(dst, scaled.Rect.Add(image.Pt(x, y)), scaled, image.Point{}, draw.Over)
+}
@@ -0,0 +1,346 @@
+// Package region implements sets of integer points as unions of
+// rectangles, the arithmetic behind wl_region, opaque and input regions and
+// damage.
+//
+// Like pixman's regions, a Region is stored in bands: horizontal strips in
+// which every rectangle spans the same rows. Within a band the rectangles are
+// sorted by x and neither overlap nor touch, and bands are sorted by y and do
+// not overlap. Vertically adjacent bands with the same spans are merged, so
+// a region has a single representation and two regions are equal exactly
+// when their rectangles are.
+package region
+
+import (
+	"image"
+	"math"
+)
+
+// A Region is a set of points. The zero value is the empty region. Regions
+// are values: operations return new regions and never change their
+// operands, so a Region can be copied and shared freely.
+type Region struct {
+	rects []image.Rectangle
+}
+
+// Rect returns the region covering r.
+func Rect(r image.Rectangle) Region {
+	if r.Empty() {
+		return Region{}
+	}
+	return Region{rects: []image.Rectangle{r}}
+}
+
+// Infinite returns a region covering every point with 32-bit coordinates,
+// such as a surface's default input region.
+func Infinite() Region {
+	return Rect(image.Rect(math.MinInt32, math.MinInt32, math.MaxInt32, math.MaxInt32))
+}
+
+// FromRects returns the union of rects.
+func FromRects(rects ...image.Rectangle) Region {
+	var r Region
+	for _, v := range rects {
+		r = r.UnionRect(v)
+	}
+	return r
+}
+
+// Empty reports whether the region has no points.
+func (r Region) Empty() bool {
+	return len(r.rects) == 0
+}
+
+// Rects returns the rectangles making up the region in band order: by y,
+// then by x. The slice must not be modified.
+func (r Region) Rects() []image.Rectangle {
+	return r.rects
+}
+
+// Bounds returns the smallest rectangle containing the region.
+func (r Region) Bounds() image.Rectangle {
+	if len(r.rects) == 0 {
+		return image.Rectangle{}
+	}
+	b := image.Rectangle{
+		Min: image.Pt(r.rects[0].Min.X, r.rects[0].Min.Y),
+		Max: image.Pt(r.rects[0].Max.X, r.rects[len(r.rects)-1].Max.Y),
+	}
+	for _, v := range r.rects {
+		if v.Min.X < b.Min.X {
+			b.Min.X = v.Min.X
+		}
+		if v.Max.X > b.Max.X {
+			b.Max.X = v.Max.X
+		}
+	}
+	return b
+}
+
+// Contains reports whether the point is in the region.
+func (r Region) Contains(x, y int) bool {
+	rects := r.rects
+	// Find the band holding y by binary search, then the span holding x.
+	lo, hi := 0, len(rects)
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		if rects[m].Max.Y <= y {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	for i := lo; i < len(rects) && rects[i].Min.Y <= y; i++ {
+		if x < rects[i].Min.X {
+			return false
+		}
+		if x < rects[i].Max.X {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether two regions have the same points.
+func (r Region) Equal(o Region) bool {
+	if len(r.rects) != len(o.rects) {
+		return false
+	}
+	for i, v := range r.rects {
+		if v != o.rects[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Translate returns the region moved by dx, dy.
+func (r Region) Translate(dx, dy int) Region {
+	if len(r.rects) == 0 {
+		return r
+	}
+	d := image.Pt(dx, dy)
+	rects := make([]image.Rectangle, len(r.rects))
+	for i, v := range r.rects {
+		rects[i] = v.Add(d)
+	}
+	return Region{rects: rects}
+}
+
+// Union returns the points in either region.
+func (r Region) Union(o Region) Region {
+	switch {
+	case len(o.rects) == 0:
+		return r
+	case len(r.rects) == 0:
+		return o
+	}
+	return combine(r, o, opUnion)
+}
+
+// Intersect returns the points in both regions.
+func (r Region) Intersect(o Region) Region {
+	if len(r.rects) == 0 || len(o.rects) == 0 || !r.Bounds().Overlaps(o.Bounds()) {
+		return Region{}
+	}
+	return combine(r, o, opIntersect)
+}
+
+// Subtract returns the points in r that are not in o.
+func (r Region) Subtract(o Region) Region {
+	if len(r.rects) == 0 || len(o.rects) == 0 || !r.Bounds().Overlaps(o.Bounds()) {
+		return r
+	}
+	return combine(r, o, opSubtract)
+}
+
+// UnionRect returns the union of r and the rectangle.
+func (r Region) UnionRect(rect image.Rectangle) Region {
+	return r.Union(Rect(rect))
+}
+
+// IntersectRect returns the part of r within the rectangle.
+func (r Region) IntersectRect(rect image.Rectangle) Region {
+	return r.Intersect(Rect(rect))
+}
+
+// SubtractRect returns r without the rectangle.
+func (r Region) SubtractRect(rect image.Rectangle) Region {
+	return r.Subtract(Rect(rect))
+}
+
+// Set operations, as functions of whether a point is in each operand.
+const (
+	opUnion = iota
+	opIntersect
+	opSubtract
+)
+
+func apply(op int, inA, inB bool) bool {
+	switch op {
+	case opUnion:
+		return inA || inB
+	case opIntersect:
+		return inA && inB
+	}
+	return inA && !inB
+}
+
+// combine computes a set operation band by band. The rows are cut at every
+// band edge of either operand; within each cut the two operands have fixed
+// spans, which are merged along x.
+func combine(a, b Region, op int) Region {
+	ra, rb := a.rects, b.rects
+	out := builder{rects: make([]image.Rectangle, 0, len(ra)+len(rb))}
+	// y is the top of the next strip to compute; everything above it is
+	// done.
+	y := ra[0].Min.Y
+	if rb[0].Min.Y < y {
+		y = rb[0].Min.Y
+	}
+	for len(ra) > 0 && len(rb) > 0 {
+		// Each operand contributes its current band if the band has started
+		// by y, and the strip ends at the next edge of either operand.
+		var sa, sb []image.Rectangle
+		next := ra[0].Min.Y
+		if next <= y {
+			sa = ra[:bandLen(ra)]
+			next = ra[0].Max.Y
+		}
+		if rb[0].Min.Y <= y {
+			sb = rb[:bandLen(rb)]
+			if rb[0].Max.Y < next {
+				next = rb[0].Max.Y
+			}
+		} else if rb[0].Min.Y < next {
+			next = rb[0].Min.Y
+		}
+		if sa != nil || sb != nil {
+			out.band(y, next, sa, sb, op)
+		}
+		y = next
+		if ra[0].Max.Y <= y {
+			ra = ra[bandLen(ra):]
+		}
+		if rb[0].Max.Y <= y {
+			rb = rb[bandLen(rb):]
+		}
+	}
+
+	// Once one operand is used up, the rest of the other is kept or dropped
+	// whole; its first band may have been cut at y already.
+	rest, keep := ra, apply(op, true, false)
+	if len(rb) > 0 {
+		rest, keep = rb, apply(op, false, true)
+	}
+	for keep && len(rest) > 0 {
+		n := bandLen(rest)
+		y0 := rest[0].Min.Y
+		if y0 < y {
+			y0 = y
+		}
+		out.band(y0, rest[0].Max.Y, rest[:n], nil, opUnion)
+		rest = rest[n:]
+	}
+	return Region{rects: out.rects}
+}
+
+// bandLen returns the number of rectangles in the band that rects starts
+// with.
+func bandLen(rects []image.Rectangle) int {
+	n := 1
+	for n < len(rects) && rects[n].Min.Y == rects[0].Min.Y {
+		n++
+	}
+	return n
+}
+
+// A builder collects the bands of a result, merging each with the one
+// above when they touch and have the same spans.
+type builder struct {
+	rects []image.Rectangle
+	// last is the index of the first rectangle of the last band.
+	last int
+}
+
+// band adds the strip of rows y0 to y1 with the spans that result from
+// applying op to the spans of a and b.
+func (bd *builder) band(y0, y1 int, a, b []image.Rectangle, op int) {
+	start := len(bd.rects)
+	inA, inB := false, false
+	open := false
+	x0 := 0
+	for len(a) > 0 || len(b) > 0 {
+		// Take the leftmost edge of either span list.
+		var x int
+		var fromA bool
+		switch {
+		case len(b) == 0:
+			fromA = true
+		case len(a) == 0:
+			fromA = false
+		default:
+			fromA = edge(a, inA) <= edge(b, inB)
+		}
+		if fromA {
+			x = edge(a, inA)
+			if inA {
+				a = a[1:]
+			}
+			inA = !inA
+		} else {
+			x = edge(b, inB)
+			if inB {
+				b = b[1:]
+			}
+			inB = !inB
+		}
+		// Edges at the same x are handled together, so that touching spans
+		// merge and nothing empty is emitted.
+		if (len(a) > 0 && edge(a, inA) == x) || (len(b) > 0 && edge(b, inB) == x) {
+			continue
+		}
+		in := apply(op, inA, inB)
+		switch {
+		case in && !open:
+			x0, open = x, true
+		case !in && open:
+			bd.rects = append(bd.rects, image.Rect(x0, y0, x, y1))
+			open = false
+		}
+	}
+	if len(bd.rects) == start {
+		return
+	}
+	bd.coalesce(start)
+}
+
+// edge returns the next edge of a span list: the left edge of its first
+// span, or the right edge if the span has been entered.
+func edge(spans []image.Rectangle, in bool) int {
+	if in {
+		return spans[0].Max.X
+	}
+	return spans[0].Min.X
+}
+
+// coalesce merges the band starting at start into the previous band if the
+// two touch and have the same spans.
+func (bd *builder) coalesce(start int) {
+	prev := bd.rects[bd.last:start]
+	cur := bd.rects[start:]
+	if start == 0 || len(prev) != len(cur) || prev[0].Max.Y != cur[0].Min.Y {
+		bd.last = start
+		return
+	}
+	for i := range cur {
+		if prev[i].Min.X != cur[i].Min.X || prev[i].Max.X != cur[i].Max.X {
+			bd.last = start
+			return
+		}
+	}
+	y1 := cur[0].Max.Y
+	for i := range prev {
+		prev[i].Max.Y = y1
+	}
+	bd.rects = bd.rects[:start]
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/client"
+	"github.com/Pursuit92/goland/gen/server"
+	"github.com/Pursuit92/goland/headless"
+	"github.com/Pursuit92/goland/testing/testutil"
+)
+
+// A client binds the headless compositor's globals at their latest versions
+// and creates objects through them. The objects inherit the versions of
+// the globals they come from, even where that is above their own
+// interface's, as a wl_pointer from a version 4 wl_seat does; client and
+// server must agree on them.
+
+// A child is an object created through a bound global.
+type child struct {
+	global  *gen.Interface
+	version uint32
+	opcode  uint16
+	iface   *gen.Interface
+}
+
+var children = []child{
+	{gen.WlSeatInterface, 4, 0, gen.WlPointerInterface},
+	{gen.WlSeatInterface, 4, 2, gen.WlTouchInterface},
+	{gen.WlCompositorInterface, 3, 1, gen.WlRegionInterface},
+	{gen.WlDataDeviceManagerInterface, 2, 0, gen.WlDataSourceInterface},
+}
+
+func versionsTest() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	d, err := server.NewDisplay()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := headless.New(d, headless.Options{Width: 64, Height: 64}); err != nil {
+		log.Fatal(err)
+	}
+	ran := make(chan error, 1)
+	go func() { ran <- d.Run(ctx) }()
+
+	c1, c2 := testutil.SocketPair()
+	var sc *server.Client
+	d.Invoke(func() { sc, err = d.CreateClient(c2) })
+	if err != nil {
+		log.Fatal(err)
+	}
+	display := client.NewDisplay(c1)
+	reg, err := client.NewRegistry(ctx, display)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := display.Roundtrip(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, c := range children {
+		parent, err := reg.BindFirst(ctx, c.global, c.version)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p, err := parent.MarshalConstructor(ctx, c.opcode, c.iface, gen.WlNewId(0))
+		if err != nil {
+			log.Fatalf("%s %d: creating %s: %v", c.global.Name, c.version, c.iface.Name, err)
+		}
+		if _, err := display.Roundtrip(ctx); err != nil {
+			log.Fatalf("%s %d: creating %s: %v", c.global.Name, c.version, c.iface.Name, err)
+		}
+		var version uint32
+		d.Invoke(func() {
+			if r := sc.Resource(p.Id()); r != nil {
+				version = r.Version()
+			}
+		})
+		if p.Version() != c.version || version != c.version {
+			log.Fatalf("%s %d: %s is version %d on the client and %d on the server",
+				c.global.Name, c.version, c.iface.Name, p.Version(), version)
+		}
+		fmt.Printf("%s %d: %s version %d\n", c.global.Name, c.version, c.iface.Name, version)
+	}
+
+	// Explicitly versioned constructors are still checked.
+	seat := reg.Find("wl_seat")[0]
+	_, err = reg.MarshalConstructorVersioned(ctx, 0, gen.WlSeatInterface, gen.WlSeatInterface.Version+1,
+		gen.WlUint(seat.Name), gen.WlString("wl_seat"), gen.WlUint(gen.WlSeatInterface.Version+1), gen.WlNewId(0))
+	if err == nil {
+		log.Fatal("bound wl_seat above its version")
+	}
+
+	d.Invoke(func() { d.Close() })
+	if err := <-ran; err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("PASS")
+}
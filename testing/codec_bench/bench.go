@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/testing/testutil"
+)
+
+// Each batch models a burst of wl_pointer.motion events: 8 bytes of header
+// plus time, surface_x and surface_y.
+const (
+	motionSize      = 20
+	motionsPerBatch = 32
+)
+
+var benchmarks = []struct {
+	name string
+	fn   func(b *testing.B)
+}{
+	{"ReadMsg", benchReadMsg},
+	{"SendMsg", benchSendMsg},
+	{"RoundTrip", benchRoundTrip},
+}
+
+var ctx = context.Background()
+
+func runBenchmarks() {
+	for _, v := range benchmarks {
+		r := testing.Benchmark(v.fn)
+		fmt.Printf("%-10s %s %s %.2f allocs/msg\n", v.name, r, r.MemString(),
+			float64(r.AllocsPerOp())/motionsPerBatch)
+	}
+}
+
+func motionBatch() *gen.WlWireMessage {
+	wmsg := &gen.WlWireMessage{Messages: make([]gen.WlMessage, motionsPerBatch)}
+	for i := range wmsg.Messages {
+		wmsg.Messages[i] = gen.WlMessage{
+			WlHeader: gen.WlHeader{Id: 3, Op: 2, Size: motionSize},
+			Data:     make([]byte, motionSize-8),
+		}
+	}
+	return wmsg
+}
+
+func benchReadMsg(b *testing.B) {
+	c1, c2 := testutil.SocketPair()
+	defer c1.Close()
+	defer c2.Close()
+
+	// Capture the encoded batch once so the loop only measures decoding.
+	wire := make([]byte, motionSize*motionsPerBatch)
+	if err := gen.SendMsg(ctx, c1, motionBatch()); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := c2.Read(wire); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if _, err := c1.Write(wire); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		wmsg, err := gen.ReadMsg(ctx, c2)
+		if err != nil {
+			b.Fatal(err)
+		}
+		wmsg.Release()
+	}
+}
+
+func benchSendMsg(b *testing.B) {
+	c1, c2 := testutil.SocketPair()
+	defer c1.Close()
+	defer c2.Close()
+
+	wmsg := motionBatch()
+	sink := make([]byte, motionSize*motionsPerBatch)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := gen.SendMsg(ctx, c1, wmsg); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		if _, err := c2.Read(sink); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
+
+func benchRoundTrip(b *testing.B) {
+	c1, c2 := testutil.SocketPair()
+	defer c1.Close()
+	defer c2.Close()
+
+	wmsg := motionBatch()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := gen.SendMsg(ctx, c1, wmsg); err != nil {
+			b.Fatal(err)
+		}
+		rmsg, err := gen.ReadMsg(ctx, c2)
+		if err != nil {
+			b.Fatal(err)
+		}
+		rmsg.Release()
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/Pursuit92/goland/gen/server"
+	"github.com/Pursuit92/goland/headless"
+)
+
+var (
+	socketName = flag.String("socket", "", "display socket name (default: first free wayland-N)")
+	width      = flag.Int("width", 1024, "output width")
+	height     = flag.Int("height", 768, "output height")
+	dumpPath   = flag.String("dump", "", "write the framebuffer as PNG here on SIGUSR1 and on exit")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] [command [args...]]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Runs a headless compositor. If a command is given, it is run as a client\n")
+		fmt.Fprintf(os.Stderr, "and the compositor exits with its status when it exits.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run())
+}
+
+func run() int {
+	d, err := server.NewDisplay()
+	if err != nil {
+		log.Fatal(err)
+	}
+	comp, err := headless.New(d, headless.Options{Width: *width, Height: *height})
+	if err != nil {
+		log.Fatal(err)
+	}
+	name := *socketName
+	if name == "" {
+		name, err = d.AddSocketAuto()
+	} else {
+		err = d.AddSocket(name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("listening on %s", name)
+
+	status := 0
+	loop := d.EventLoop()
+	if _, err := loop.AddSignal(func(os.Signal) { dump(comp) }, syscall.SIGUSR1); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := loop.AddSignal(func(os.Signal) { d.Close() }, os.Interrupt, syscall.SIGTERM); err != nil {
+		log.Fatal(err)
+	}
+
+	if args := flag.Args(); len(args) > 0 {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		cmd.Env = append(os.Environ(), "WAYLAND_DISPLAY="+name)
+		if err := cmd.Start(); err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			err := cmd.Wait()
+			d.Invoke(func() {
+				if exit, ok := err.(*exec.ExitError); ok {
+					status = exit.ExitCode()
+				} else if err != nil {
+					log.Print(err)
+					status = 1
+				}
+				d.Close()
+			})
+		}()
+	}
+
+	if err := d.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	dump(comp)
+	return status
+}
+
+// dump writes the framebuffer to the -dump file, if one was given.
+func dump(comp *headless.Compositor) {
+	if *dumpPath == "" {
+		return
+	}
+	f, err := os.Create(*dumpPath)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer f.Close()
+	if err := png.Encode(f, comp.Snapshot()); err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/Pursuit92/goland/region"
+)
+
+var benchmarks = []struct {
+	name string
+	fn   func(b *testing.B)
+}{
+	{"UnionRects", benchUnionRects},
+	{"DamageTiles", benchDamageTiles},
+	{"Union", benchUnion},
+	{"Intersect", benchIntersect},
+	{"Subtract", benchSubtract},
+	{"Contains", benchContains},
+}
+
+func runBenchmarks() {
+	for _, v := range benchmarks {
+		r := testing.Benchmark(v.fn)
+		fmt.Printf("%-12s %s %s\n", v.name, r, r.MemString())
+	}
+}
+
+// randomRects returns n rectangles of up to 64x64 scattered over a
+// 1024x768 output, the same ones on every run.
+func randomRects(seed int64, n int) []image.Rectangle {
+	rnd := rand.New(rand.NewSource(seed))
+	rects := make([]image.Rectangle, n)
+	for i := range rects {
+		x, y := rnd.Intn(1024), rnd.Intn(768)
+		rects[i] = image.Rect(x, y, x+1+rnd.Intn(64), y+1+rnd.Intn(64))
+	}
+	return rects
+}
+
+// benchUnionRects builds a region one rectangle at a time, as wl_region.add
+// requests do.
+func benchUnionRects(b *testing.B) {
+	rects := randomRects(1, 100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var r region.Region
+		for _, v := range rects {
+			r = r.UnionRect(v)
+		}
+	}
+}
+
+// benchDamageTiles damages a 1024x768 surface in 16x16 tiles, the worst
+// case for merging: every tile touches the ones before it.
+func benchDamageTiles(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var r region.Region
+		for y := 0; y < 768; y += 16 {
+			for x := 0; x < 1024; x += 16 {
+				r = r.UnionRect(image.Rect(x, y, x+16, y+16))
+			}
+		}
+		if len(r.Rects()) != 1 {
+			b.Fatal("tiles did not merge")
+		}
+	}
+}
+
+func benchOp(b *testing.B, op func(a, c region.Region) region.Region) {
+	a := region.FromRects(randomRects(1, 100)...)
+	c := region.FromRects(randomRects(2, 100)...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		op(a, c)
+	}
+}
+
+func benchUnion(b *testing.B) {
+	benchOp(b, region.Region.Union)
+}
+
+func benchIntersect(b *testing.B) {
+	benchOp(b, region.Region.Intersect)
+}
+
+func benchSubtract(b *testing.B) {
+	benchOp(b, region.Region.Subtract)
+}
+
+func benchContains(b *testing.B) {
+	r := region.FromRects(randomRects(1, 100)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Contains(i%1024, (i/1024)%768)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/client"
+	"github.com/Pursuit92/goland/gen/server"
+	"github.com/Pursuit92/goland/headless"
+	"github.com/Pursuit92/goland/region"
+	"github.com/Pursuit92/goland/testing/testutil"
+)
+
+// A client builds regions with wl_region.add and subtract, and the server's
+// regions are checked against the expected ones. Rectangles with no area
+// must be ignored rather than flipped, and the edges of rectangles near the
+// limits of int32 must not wrap around.
+
+const (
+	add      = 1
+	subtract = 2
+)
+
+// An op is a wl_region request, add or subtract.
+type op struct {
+	opcode              uint16
+	x, y, width, height int32
+}
+
+var tests = []struct {
+	name string
+	ops  []op
+	want region.Region
+}{
+	{"add", []op{{add, 0, 0, 10, 10}}, region.Rect(image.Rect(0, 0, 10, 10))},
+	{"add negative size", []op{{add, 10, 10, -5, -5}}, region.Region{}},
+	{"add negative width", []op{{add, 10, 0, -5, 10}}, region.Region{}},
+	{"add zero height", []op{{add, 0, 0, 10, 0}}, region.Region{}},
+	{"subtract negative size", []op{{add, 0, 0, 10, 10}, {subtract, 10, 10, -5, -5}},
+		region.Rect(image.Rect(0, 0, 10, 10))},
+	{"subtract", []op{{add, 0, 0, 10, 10}, {subtract, 5, 0, 5, 10}},
+		region.Rect(image.Rect(0, 0, 5, 10))},
+	{"add past MaxInt32", []op{{add, math.MaxInt32 - 10, math.MaxInt32 - 10, 20, 20}},
+		region.Rect(image.Rect(math.MaxInt32-10, math.MaxInt32-10, math.MaxInt32+10, math.MaxInt32+10))},
+	{"add from MinInt32", []op{{add, math.MinInt32, math.MinInt32, math.MaxInt32, math.MaxInt32}},
+		region.Rect(image.Rect(math.MinInt32, math.MinInt32, -1, -1))},
+	{"subtract past MaxInt32", []op{{add, 0, 0, math.MaxInt32, 10}, {subtract, math.MaxInt32 - 10, 0, math.MaxInt32, 10}},
+		region.Rect(image.Rect(0, 0, math.MaxInt32-10, 10))},
+}
+
+func regionTest() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	d, err := server.NewDisplay()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := headless.New(d, headless.Options{Width: 64, Height: 64}); err != nil {
+		log.Fatal(err)
+	}
+	ran := make(chan error, 1)
+	go func() { ran <- d.Run(ctx) }()
+
+	c1, c2 := testutil.SocketPair()
+	var sc *server.Client
+	d.Invoke(func() { sc, err = d.CreateClient(c2) })
+	if err != nil {
+		log.Fatal(err)
+	}
+	display := client.NewDisplay(c1)
+	reg, err := client.NewRegistry(ctx, display)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := display.Roundtrip(ctx); err != nil {
+		log.Fatal(err)
+	}
+	compositor, err := reg.BindFirst(ctx, gen.WlCompositorInterface, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := false
+	for _, tt := range tests {
+		r, err := compositor.MarshalConstructor(ctx, 1, gen.WlRegionInterface, gen.WlNewId(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, o := range tt.ops {
+			if err := r.Marshal(ctx, o.opcode, gen.WlInt(o.x), gen.WlInt(o.y), gen.WlInt(o.width), gen.WlInt(o.height)); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if _, err := display.Roundtrip(ctx); err != nil {
+			log.Fatalf("%s: %v", tt.name, err)
+		}
+		var got region.Region
+		d.Invoke(func() { got = *server.RegionFromResource(sc.Resource(r.Id())) })
+		if !got.Equal(tt.want) {
+			fmt.Printf("%s: got %v, want %v\n", tt.name, got.Rects(), tt.want.Rects())
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: ok\n", tt.name)
+	}
+
+	d.Invoke(func() { d.Close() })
+	if err := <-ran; err != nil {
+		log.Fatal(err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Println("PASS")
+}
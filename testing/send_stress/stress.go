@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/client"
+	"github.com/Pursuit92/goland/testing/testutil"
+	"golang.org/x/sys/unix"
+)
+
+// Each sender goroutine gets its own registry and sends numbered
+// wl_registry.bind requests on it, followed by a wl_shm.create_pool whose fd
+// is a memfd as long as the pool's size. The fake server checks that new IDs
+// arrive in allocation order, that every registry sees its binds in order,
+// and that each create_pool carries its own fd and no fd is left over. Any
+// error reading the stream fails the test; only the client hanging up ends
+// it.
+const (
+	senders   = 16
+	perSender = 500
+)
+
+func stressTest() {
+	ctx := context.Background()
+	c1, c2 := testutil.SocketPair()
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- fakeServer(c2) }()
+
+	display := client.NewDisplay(c1)
+	sendErrs := make(chan error, senders+1)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sendErrs <- sender(ctx, display)
+		}()
+	}
+	wg.Wait()
+	_, err := display.Roundtrip(ctx)
+	sendErrs <- err
+	display.Close()
+	close(sendErrs)
+
+	// The server's complaint explains any failure the senders saw, which
+	// is usually just the connection being closed under them.
+	if err := <-serverErr; err != nil {
+		log.Fatal("server: ", err)
+	}
+	for err := range sendErrs {
+		if err != nil {
+			log.Fatal("client: ", err)
+		}
+	}
+	fmt.Printf("ok: %d goroutines sent %d requests each\n", senders, 2*perSender)
+}
+
+func sender(ctx context.Context, display *client.Display) error {
+	registry, err := display.GetRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	for seq := 1; seq <= perSender; seq++ {
+		shm, err := registry.MarshalConstructorVersioned(ctx, 0, gen.WlShmInterface, 1,
+			gen.WlUint(seq), gen.WlString("wl_shm"), gen.WlUint(1), gen.WlNewId(0))
+		if err != nil {
+			return err
+		}
+
+		fd, err := unix.MemfdCreate("stress", unix.MFD_CLOEXEC)
+		if err != nil {
+			return err
+		}
+		if err := unix.Ftruncate(fd, int64(seq)); err != nil {
+			return err
+		}
+		_, err = shm.MarshalConstructor(ctx, 0, gen.WlShmPoolInterface, gen.WlNewId(0), gen.WlFd(fd), gen.WlInt(seq))
+		unix.Close(fd)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fakeServer(c *net.UnixConn) error {
+	conn := gen.NewConn(c)
+	defer conn.Close()
+
+	objects := map[uint32]*gen.Interface{1: gen.WlDisplayInterface}
+	lastBind := map[uint32]uint32{}
+	nextId := uint32(2)
+	newObject := func(id uint32, iface *gen.Interface) error {
+		if id != nextId {
+			return fmt.Errorf("new object %d out of order, expected %d", id, nextId)
+		}
+		nextId++
+		objects[id] = iface
+		return nil
+	}
+
+	for {
+		msgs, err := conn.ReadMessages(context.Background(), nil)
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			// Every fd must have gone to the request that carried it.
+			if n := conn.PendingFDs(); n != 0 {
+				return fmt.Errorf("%d fds left over", n)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			iface := objects[msg.Id]
+			if iface == nil || int(msg.Op) >= len(iface.Requests) {
+				return fmt.Errorf("bad request %d on object %d", msg.Op, msg.Id)
+			}
+			req := &iface.Requests[msg.Op]
+			if n := conn.PendingFDs(); n < req.NumFDs() {
+				return fmt.Errorf("%s.%s carries %d fds, %d received", iface.Name, req.Name, req.NumFDs(), n)
+			}
+			args, err := conn.Unmarshal(req, msg)
+			if err != nil {
+				return err
+			}
+
+			switch iface {
+			case gen.WlDisplayInterface:
+				id := uint32(args[0].(gen.WlNewId))
+				if msg.Op == 1 {
+					err = newObject(id, gen.WlRegistryInterface)
+					break
+				}
+				if err = newObject(id, gen.WlCallbackInterface); err != nil {
+					break
+				}
+				done, _, _ := gen.NewMessage(id, 0, &gen.WlCallbackInterface.Events[0], gen.WlUint(0))
+				err = conn.WriteMessage(context.Background(), done, nil)
+			case gen.WlRegistryInterface:
+				seq := uint32(args[0].(gen.WlUint))
+				if seq != lastBind[msg.Id]+1 {
+					return fmt.Errorf("registry %d: bind %d after %d", msg.Id, seq, lastBind[msg.Id])
+				}
+				lastBind[msg.Id] = seq
+				err = newObject(uint32(args[3].(gen.WlNewId)), gen.WlShmInterface)
+			case gen.WlShmInterface:
+				if err = newObject(uint32(args[0].(gen.WlNewId)), gen.WlShmPoolInterface); err != nil {
+					break
+				}
+				fd := int(args[1].(gen.WlFd))
+				var st unix.Stat_t
+				err = unix.Fstat(fd, &st)
+				unix.Close(fd)
+				if err == nil && st.Size != int64(args[2].(gen.WlInt)) {
+					err = fmt.Errorf("create_pool size %d got fd of size %d", args[2], st.Size)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/client"
+	"github.com/Pursuit92/goland/gen/server"
+	"github.com/Pursuit92/goland/testing/testutil"
+	"golang.org/x/sys/unix"
+)
+
+// A client creates a pool on a memfd, carves a buffer out of it and then
+// truncates the file. Reading the buffer would kill an unguarded server
+// with SIGBUS; this one must instead disconnect the client with
+// wl_shm.invalid_fd and go on serving a second client, whose memfd is
+// sealed against shrinking.
+const (
+	width  = 64
+	height = 64
+	stride = width * 4
+	size   = stride * height
+)
+
+// testClient is a client connected to the display with a buffer in a pool
+// on the memfd fd.
+type testClient struct {
+	display *client.Display
+	buffer  *client.Proxy
+	fd      int
+	server  *server.Client
+}
+
+func connect(ctx context.Context, d *server.Display, sealed bool) (*testClient, error) {
+	c1, c2 := testutil.SocketPair()
+	tc := &testClient{}
+	var err error
+	d.Invoke(func() { tc.server, err = d.CreateClient(c2) })
+	if err != nil {
+		return nil, err
+	}
+	tc.display = client.NewDisplay(c1)
+	reg, err := client.NewRegistry(ctx, tc.display)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tc.display.Roundtrip(ctx); err != nil {
+		return nil, err
+	}
+	shm, err := reg.BindFirst(ctx, gen.WlShmInterface, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := unix.MFD_CLOEXEC
+	if sealed {
+		flags |= unix.MFD_ALLOW_SEALING
+	}
+	tc.fd, err = unix.MemfdCreate("shm-sigbus", flags)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Ftruncate(tc.fd, size); err != nil {
+		return nil, err
+	}
+	if sealed {
+		if _, err := unix.FcntlInt(uintptr(tc.fd), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK); err != nil {
+			return nil, err
+		}
+	}
+	pool, err := shm.MarshalConstructor(ctx, 0, gen.WlShmPoolInterface, gen.WlNewId(0), gen.WlFd(tc.fd), gen.WlInt(size))
+	if err != nil {
+		return nil, err
+	}
+	tc.buffer, err = pool.MarshalConstructor(ctx, 0, gen.WlBufferInterface, gen.WlNewId(0),
+		gen.WlInt(0), gen.WlInt(width), gen.WlInt(height), gen.WlInt(stride), gen.WlUint(gen.WlShmXrgb8888))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tc.display.Roundtrip(ctx); err != nil {
+		return nil, err
+	}
+	return tc, nil
+}
+
+// readBuffer reads every byte of the client's buffer on the server.
+func readBuffer(d *server.Display, tc *testClient) error {
+	var err error
+	ran := d.Invoke(func() {
+		b := server.ShmBufferFromResource(tc.server.Resource(tc.buffer.Id()))
+		if b == nil {
+			err = errors.New("buffer not found on the server")
+			return
+		}
+		var sum byte
+		err = b.Access(func() {
+			for _, v := range b.Data() {
+				sum += v
+			}
+		})
+	})
+	if !ran {
+		return errors.New("display closed")
+	}
+	return err
+}
+
+func sigbusTest() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	d, err := server.NewDisplay()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ran := make(chan error, 1)
+	go func() { ran <- d.Run(ctx) }()
+	d.Invoke(func() { _, err = d.InitShm() })
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bad, err := connect(ctx, d, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := unix.Ftruncate(bad.fd, 0); err != nil {
+		log.Fatal(err)
+	}
+	if err := readBuffer(d, bad); err != server.ErrShmAccess {
+		log.Fatalf("reading the truncated buffer: got %v, want %v", err, server.ErrShmAccess)
+	}
+	_, err = bad.display.Roundtrip(ctx)
+	perr, ok := err.(*client.ProtocolError)
+	if !ok || perr.Interface != "wl_shm" || perr.Name != "invalid_fd" {
+		log.Fatalf("truncating client: got %v, want a wl_shm.invalid_fd protocol error", err)
+	}
+	fmt.Println("truncated pool:", perr)
+
+	good, err := connect(ctx, d, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := unix.Ftruncate(good.fd, 0); err == nil {
+		log.Fatal("truncated a memfd sealed against shrinking")
+	}
+	if err := readBuffer(d, good); err != nil {
+		log.Fatal("reading the sealed buffer: ", err)
+	}
+	if _, err := good.display.Roundtrip(ctx); err != nil {
+		log.Fatal("sealed client: ", err)
+	}
+	fmt.Println("sealed pool: ok")
+
+	d.Invoke(func() { d.Close() })
+	if err := <-ran; err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("PASS")
+}
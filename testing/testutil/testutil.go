@@ -0,0 +1,30 @@
+// Package testutil holds helpers shared by the test programs under testing.
+package testutil
+
+import (
+	"log"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// SocketPair returns the two ends of a connected unix stream socket, for
+// joining a client and a server in one process. It exits on failure.
+func SocketPair() (*net.UnixConn, *net.UnixConn) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conns := make([]*net.UnixConn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		conns[i] = c.(*net.UnixConn)
+	}
+	return conns[0], conns[1]
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
-	"os"
 	"runtime"
 
 	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/client"
+	"github.com/Pursuit92/goland/gen/server"
 	"github.com/davecgh/go-spew/spew"
+	"golang.org/x/sys/unix"
 )
 
 var msgs chan string
@@ -19,20 +22,14 @@ func printMsgs(msgs chan string) {
 	}
 }
 
-var compositorName = "/run/user/1000/compositor"
+var compositorName = "compositor"
 
 func socketTest() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
-start:
-	sock, err := net.ListenUnix("unix", &net.UnixAddr{Name: compositorName, Net: "unix"})
+	sock, err := server.Listen(compositorName)
 	if err != nil {
-		if _, err := net.Dial("unix", compositorName); err != nil {
-			os.Remove(compositorName)
-			goto start
-		} else {
-			log.Fatal("socket is in use")
-		}
+		log.Fatal(err)
 	}
 	defer sock.Close()
 	msgs = make(chan string)
@@ -47,19 +44,40 @@ start:
 	}
 }
 
-var wAddr = &net.UnixAddr{
-	Name: "/run/user/1000/weston",
-	Net:  "unix",
+var westonName = "weston"
+
+// peerName describes the process at the other end of conn.
+func peerName(conn *net.UnixConn) string {
+	cred, err := server.PeerCredentials(conn)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("pid %d (uid %d, gid %d)", cred.Pid, cred.Uid, cred.Gid)
 }
 
 func unixSockPipe(conn1, conn2 *net.UnixConn) {
+	name := peerName(conn1)
+	in, out := gen.NewConn(conn1), gen.NewConn(conn2)
+	var batch []gen.WlMessage
 	for {
-		msg, err := gen.ReadMsg(conn1)
+		var err error
+		batch, err = in.ReadMessages(context.Background(), batch[:0])
 		if err != nil {
 			return
 		}
-		msgs <- fmt.Sprintf("%s:\n%s", conn1.RemoteAddr().String(), spew.Sdump(msg))
-		err = gen.SendMsg(conn2, msg)
+		if len(batch) == 0 {
+			// Only part of a message so far; its fds stay queued.
+			continue
+		}
+		// The pipe does not know the messages' signatures, so the fds
+		// received so far go along with the batch, which keeps them
+		// ahead of the messages that carry them.
+		fds, _ := in.TakeFDs(in.PendingFDs())
+		msgs <- fmt.Sprintf("%s:\n%s", name, spew.Sdump(batch, fds))
+		err = out.WriteMessages(context.Background(), batch, fds)
+		for _, fd := range fds {
+			unix.Close(fd)
+		}
 		if err != nil {
 			return
 		}
@@ -68,7 +86,7 @@ func unixSockPipe(conn1, conn2 *net.UnixConn) {
 
 func handleConn(conn *net.UnixConn) {
 	defer conn.Close()
-	westConn, err := net.DialUnix("unix", nil, wAddr)
+	westConn, err := client.Dial(westonName)
 	if err != nil {
 		log.Println(err)
 		return
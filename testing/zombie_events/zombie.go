@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/Pursuit92/goland/gen"
+	"github.com/Pursuit92/goland/gen/client"
+	"github.com/Pursuit92/goland/testing/testutil"
+)
+
+// A client releases one of its two data devices, and a fake server that has
+// not yet seen the release sends it a wl_data_offer anyway, followed by an
+// offer for the other device. The first offer's server allocated ID must be
+// reserved although nobody will see it, or the second one, which comes
+// right after it, would not fit in the object map. The client then destroys
+// the second offer, and the server reuses its ID.
+const (
+	firstOffer  = 0xff000000
+	secondOffer = 0xff000001
+)
+
+func zombieTest() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c1, c2 := testutil.SocketPair()
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- fakeServer(c2) }()
+	display := client.NewDisplay(c1)
+	defer display.Close()
+
+	registry, err := display.GetRegistry(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	manager, err := registry.MarshalConstructorVersioned(ctx, 0, gen.WlDataDeviceManagerInterface, 2,
+		gen.WlUint(1), gen.WlString("wl_data_device_manager"), gen.WlUint(2), gen.WlNewId(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	seat, err := registry.MarshalConstructorVersioned(ctx, 0, gen.WlSeatInterface, 1,
+		gen.WlUint(2), gen.WlString("wl_seat"), gen.WlUint(1), gen.WlNewId(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var devices [2]*client.Proxy
+	for i := range devices {
+		devices[i], err = manager.MarshalConstructor(ctx, 1, gen.WlDataDeviceInterface, gen.WlNewId(0), gen.WlObject(seat.Id()))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	sub := devices[1].Subscribe(16, client.Block)
+	if err := devices[0].Marshal(ctx, 2); err != nil { // release
+		log.Fatal(err)
+	}
+
+	// The server sends the offers ahead of the reply to the sync.
+	if _, err := display.Roundtrip(ctx); err != nil {
+		log.Fatal("first offers: ", err)
+	}
+	if err := expectOffer(display, sub, secondOffer); err != nil {
+		log.Fatal(err)
+	}
+	if display.Object(firstOffer) != nil {
+		log.Fatal("the offer to the released device is live")
+	}
+	fmt.Println("offer to a released device: ok")
+
+	if err := display.Object(secondOffer).Marshal(ctx, 2); err != nil { // destroy
+		log.Fatal(err)
+	}
+	if _, err := display.Roundtrip(ctx); err != nil {
+		log.Fatal("reused offer ID: ", err)
+	}
+	if err := expectOffer(display, sub, secondOffer); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("reused offer ID: ok")
+
+	display.Close()
+	if err := <-serverErr; err != nil {
+		log.Fatal("server: ", err)
+	}
+	fmt.Println("PASS")
+}
+
+// expectOffer checks that a data device was sent the offer id as its
+// selection.
+func expectOffer(display *client.Display, sub *client.Subscription, id uint32) error {
+	var got []interface{}
+	for len(got) < 2 {
+		select {
+		case ev := <-sub.C:
+			got = append(got, ev.Value)
+		default:
+			return fmt.Errorf("got events %v, want a data_offer and a selection", got)
+		}
+	}
+	offer, ok1 := got[0].(gen.WlDataDeviceDataOfferEvent)
+	sel, ok2 := got[1].(gen.WlDataDeviceSelectionEvent)
+	if !ok1 || !ok2 || uint32(offer.Id) != id || uint32(sel.Id) != id {
+		return fmt.Errorf("got events %v, want a data_offer and a selection of %#x", got, id)
+	}
+	if p := display.Object(id); p == nil || p.Interface() != gen.WlDataOfferInterface {
+		return fmt.Errorf("offer %#x is not a live wl_data_offer", id)
+	}
+	return nil
+}
+
+// fakeServer answers the client's two syncs. Before the first it sends an
+// offer to each data device, the first of which the client has released;
+// before the second it reuses the ID of the offer the client destroyed.
+func fakeServer(c *net.UnixConn) error {
+	conn := gen.NewConn(c)
+	defer conn.Close()
+
+	objects := map[uint32]*gen.Interface{1: gen.WlDisplayInterface}
+	var devices []uint32
+	syncs := 0
+	send := func(id uint32, iface *gen.Interface, op uint16, args ...interface{}) error {
+		msg, fds, err := gen.NewMessage(id, op, &iface.Events[op], args...)
+		if err != nil {
+			return err
+		}
+		return conn.WriteMessage(context.Background(), msg, fds)
+	}
+	offer := func(device, id uint32) error {
+		objects[id] = gen.WlDataOfferInterface
+		if err := send(device, gen.WlDataDeviceInterface, 0, gen.WlNewId(id)); err != nil {
+			return err
+		}
+		if err := send(id, gen.WlDataOfferInterface, 0, gen.WlString("text/plain")); err != nil {
+			return err
+		}
+		return send(device, gen.WlDataDeviceInterface, 5, gen.WlObject(id))
+	}
+
+	for {
+		msgs, err := conn.ReadMessages(context.Background(), nil)
+		if err != nil {
+			return nil
+		}
+		for _, msg := range msgs {
+			iface := objects[msg.Id]
+			if iface == nil || int(msg.Op) >= len(iface.Requests) {
+				return fmt.Errorf("bad request %d on object %d", msg.Op, msg.Id)
+			}
+			args, err := conn.Unmarshal(&iface.Requests[msg.Op], msg)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case iface == gen.WlDisplayInterface && msg.Op == 1:
+				objects[uint32(args[0].(gen.WlNewId))] = gen.WlRegistryInterface
+			case iface == gen.WlRegistryInterface:
+				objects[uint32(args[3].(gen.WlNewId))] = gen.LookupInterface(string(args[1].(gen.WlString)))
+			case iface == gen.WlDataDeviceManagerInterface && msg.Op == 1:
+				id := uint32(args[0].(gen.WlNewId))
+				objects[id] = gen.WlDataDeviceInterface
+				devices = append(devices, id)
+			case iface == gen.WlDisplayInterface && msg.Op == 0:
+				syncs++
+				switch syncs {
+				case 1:
+					if err = offer(devices[0], firstOffer); err == nil {
+						err = offer(devices[1], secondOffer)
+					}
+				case 2:
+					err = offer(devices[1], secondOffer)
+				}
+				if err == nil {
+					err = send(uint32(args[0].(gen.WlNewId)), gen.WlCallbackInterface, 0, gen.WlUint(0))
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
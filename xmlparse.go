@@ -28,6 +28,7 @@ type Request struct {
 	Name        string      `xml:"name,attr"`
 	Description Description `xml:"description"`
 	Type        string      `xml:"type,attr"`
+	Since       string      `xml:"since,attr"`
 	Args        []Arg       `xml:"arg"`
 }
 
@@ -36,6 +37,7 @@ type Event struct {
 	Name        string      `xml:"name,attr"`
 	Description Description `xml:"description"`
 	Type        string      `xml:"type,attr"`
+	Since       string      `xml:"since,attr"`
 	Args        []Arg       `xml:"arg"`
 }
 